@@ -0,0 +1,241 @@
+package rsvp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+)
+
+// Codec provides rsvp with a way to render [Body.Data] as a particular media type.
+//
+// Custom codecs may be registered with [Config.Codecs].
+type Codec interface {
+	// MediaType is the media type that this Codec renders, e.g. application/yaml
+	MediaType() string
+	// ContentType is the value that the Content-Type header is set to when this Codec is chosen, e.g. application/yaml; charset=utf-8
+	ContentType() string
+	// Extensions are the URL path extensions (without the leading '.') that select this Codec on GET requests.
+	Extensions() []string
+	// CanEncode reports whether this Codec is able to render res. [Body.MediaTypes] will only offer MediaType if CanEncode returns true.
+	CanEncode(res *Body, cfg Config) bool
+	// Encode renders res to w.
+	Encode(w io.Writer, res *Body, cfg Config) error
+}
+
+// builtinCodecs are offered in this order, ahead of [Config.Codecs]. It must not be modified.
+var builtinCodecs = []Codec{
+	stringCodec{},
+	bytesCodec{},
+	problemJsonCodec{},
+	problemXmlCodec{},
+	jsonCodec{},
+	xmlCodec{},
+	ndjsonCodec{},
+	jsonlCodec{},
+	jsonSeqCodec{},
+	csvCodec{},
+	tsvCodec{},
+	markdownTableCodec{},
+	htmlTableCodec{},
+	plainTableCodec{},
+	atomCodec{},
+	rssCodec{},
+	htmlTemplateCodec{},
+	textTemplateCodec{},
+}
+
+// registry yields every Codec available to cfg in the order that they are offered.
+func (cfg Config) registry() iter.Seq[Codec] {
+	return func(yield func(Codec) bool) {
+		for _, codecs := range [2][]Codec{builtinCodecs, cfg.Codecs} {
+			for _, c := range codecs {
+				if slices.Contains(cfg.ExcludeMediaTypes, c.MediaType()) {
+					continue
+				}
+				if !yield(c) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// lookupCodec finds the last registered Codec for mediaType, so that [Config.Codecs] may override the built-in codecs.
+func (cfg Config) lookupCodec(mediaType string) Codec {
+	var found Codec
+	for c := range cfg.registry() {
+		if c.MediaType() == mediaType {
+			found = c
+		}
+	}
+	return found
+}
+
+// lookupEncoder finds the last registered Codec for mediaType that can encode res.
+func (cfg Config) lookupEncoder(mediaType string, res *Body) Codec {
+	var found Codec
+	for c := range cfg.registry() {
+		if c.MediaType() == mediaType && c.CanEncode(res, cfg) {
+			found = c
+		}
+	}
+	return found
+}
+
+// extMediaType finds the media type associated with the URL path extension ext.
+func (cfg Config) extMediaType(ext string) (string, bool) {
+	for c := range cfg.registry() {
+		if slices.Contains(c.Extensions(), ext) {
+			return c.MediaType(), true
+		}
+	}
+	return "", false
+}
+
+// contentType finds the Content-Type associated with mediaType.
+func (cfg Config) contentType(mediaType string) (string, bool) {
+	c := cfg.lookupCodec(mediaType)
+	if c == nil {
+		return "", false
+	}
+	return c.ContentType(), true
+}
+
+type stringCodec struct{}
+
+func (stringCodec) MediaType() string    { return SupportedMediaTypePlaintext }
+func (stringCodec) ContentType() string  { return "text/plain; charset=utf-8" }
+func (stringCodec) Extensions() []string { return []string{"txt"} }
+
+func (stringCodec) CanEncode(res *Body, cfg Config) bool {
+	_, ok := res.Data.(string)
+	return ok
+}
+
+func (stringCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	data, ok := res.Data.(string)
+	if !ok {
+		return fmt.Errorf("trying to render data as %v but this type is not supported: %#v", SupportedMediaTypePlaintext, res.Data)
+	}
+	_, err := w.Write([]byte(data))
+	if err != nil {
+		return fmt.Errorf("rendering data as plain string: %w", err)
+	}
+	return nil
+}
+
+type bytesCodec struct{}
+
+func (bytesCodec) MediaType() string    { return SupportedMediaTypeBytes }
+func (bytesCodec) ContentType() string  { return "application/octet-stream" }
+func (bytesCodec) Extensions() []string { return []string{"bin"} }
+
+func (bytesCodec) CanEncode(res *Body, cfg Config) bool {
+	_, ok := res.Data.([]byte)
+	return ok
+}
+
+func (bytesCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	data, ok := res.Data.([]byte)
+	if !ok {
+		return fmt.Errorf("trying to render data as %v but this type is not supported: %#v", SupportedMediaTypeBytes, res.Data)
+	}
+	_, err := w.Write(data)
+	if err != nil {
+		return fmt.Errorf("rendering data as bytes: %w", err)
+	}
+	return nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) MediaType() string    { return SupportedMediaTypeJson }
+func (jsonCodec) ContentType() string  { return "application/json" }
+func (jsonCodec) Extensions() []string { return []string{"json"} }
+
+func (jsonCodec) CanEncode(res *Body, cfg Config) bool {
+	return true
+}
+
+func (jsonCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	var err error
+	if s, ok := asStream(res.Data); ok {
+		err = encodeJsonStream(res.Context(), w, s, cfg)
+	} else {
+		enc := json.NewEncoder(w)
+		enc.SetIndent(cfg.JsonPrefix, cfg.JsonIndent)
+		err = enc.Encode(res.Data)
+	}
+	if err != nil {
+		return fmt.Errorf("rendering data as JSON: %w", err)
+	}
+	return nil
+}
+
+const templateErrorMessage = "rsvp stopped writing here because of a template error"
+
+type htmlTemplateCodec struct{}
+
+func (htmlTemplateCodec) MediaType() string    { return SupportedMediaTypeHtml }
+func (htmlTemplateCodec) ContentType() string  { return "text/html; charset=utf-8" }
+func (htmlTemplateCodec) Extensions() []string { return []string{"html", "htm"} }
+
+func (htmlTemplateCodec) CanEncode(res *Body, cfg Config) bool {
+	return res.TemplateName != "" && cfg.HtmlTemplate != nil && cfg.HtmlTemplate.Lookup(res.TemplateName) != nil
+}
+
+func (htmlTemplateCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	if res.TemplateName == "" || cfg.HtmlTemplate == nil {
+		return fmt.Errorf("failed to render HTML because either HtmlTemplate or TemplateName is not set")
+	}
+
+	tm := cfg.HtmlTemplate.Lookup(res.TemplateName)
+	if tm == nil {
+		return fmt.Errorf("failed to match TemplateName within HtmlTemplate")
+	}
+
+	data, streamErr := res.templateData(w)
+	err := tm.ExecuteTemplate(w, res.TemplateName, data)
+	if err == nil {
+		err = streamErr()
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(w, `<span style="background-color: red; color: white;">%s</span>`, templateErrorMessage)
+		return fmt.Errorf("rendering data in html template %s: %w", res.TemplateName, err)
+	}
+	return nil
+}
+
+type textTemplateCodec struct{}
+
+func (textTemplateCodec) MediaType() string    { return SupportedMediaTypePlaintext }
+func (textTemplateCodec) ContentType() string  { return "text/plain; charset=utf-8" }
+func (textTemplateCodec) Extensions() []string { return []string{"txt"} }
+
+func (textTemplateCodec) CanEncode(res *Body, cfg Config) bool {
+	return res.TemplateName != "" && cfg.TextTemplate != nil && cfg.TextTemplate.Lookup(res.TemplateName) != nil
+}
+
+func (textTemplateCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	if res.TemplateName == "" || cfg.TextTemplate == nil {
+		return fmt.Errorf("failed to render text because either TextTemplate or TemplateName is not set")
+	}
+
+	tm := cfg.TextTemplate.Lookup(res.TemplateName)
+	if tm == nil {
+		return fmt.Errorf("failed to match TemplateName within TextTemplate")
+	}
+
+	data, streamErr := res.templateData(w)
+	err := tm.ExecuteTemplate(w, res.TemplateName, data)
+	if err == nil {
+		err = streamErr()
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "[!!ERROR!!][%s]", templateErrorMessage)
+		return fmt.Errorf("rendering data in text template %s: %w", res.TemplateName, err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package rsvp_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+type yamlCodec struct{}
+
+func (yamlCodec) MediaType() string    { return "application/yaml" }
+func (yamlCodec) ContentType() string  { return "application/yaml; charset=utf-8" }
+func (yamlCodec) Extensions() []string { return []string{"yaml", "yml"} }
+
+func (yamlCodec) CanEncode(res *rsvp.Body, cfg rsvp.Config) bool {
+	_, ok := res.Data.(map[string]string)
+	return ok
+}
+
+func (yamlCodec) Encode(w io.Writer, res *rsvp.Body, cfg rsvp.Config) error {
+	for k, v := range res.Data.(map[string]string) {
+		_, err := fmt.Fprintf(w, "%s: %s\n", k, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestCustomCodecIsOffered(t *testing.T) {
+	cfg := rsvp.Config{Codecs: []rsvp.Codec{yamlCodec{}}}
+
+	resp := rsvp.Data(map[string]string{"hello": "world"})
+	actual := slices.Collect(resp.MediaTypes(cfg))
+	assert.True(t, "yaml offered", slices.Contains(actual, "application/yaml"))
+	assert.Eq(t, "yaml offered last", "application/yaml", actual[len(actual)-1])
+
+	resp = rsvp.Data("hello")
+	actual = slices.Collect(resp.MediaTypes(cfg))
+	assert.True(t, "yaml not offered", !slices.Contains(actual, "application/yaml"))
+}
+
+func TestCustomCodecByAccept(t *testing.T) {
+	cfg := rsvp.Config{Codecs: []rsvp.Codec{yamlCodec{}}}
+	res := rsvp.Data(map[string]string{"hello": "world"})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/yaml")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", 200, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/yaml; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "body contents", "hello: world\n", rec.Body.String())
+}
+
+func TestCustomCodecByExtension(t *testing.T) {
+	cfg := rsvp.Config{Codecs: []rsvp.Codec{yamlCodec{}}}
+	res := rsvp.Data(map[string]string{"hello": "world"})
+	req := httptest.NewRequest("GET", "/greeting.yml", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", 200, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/yaml; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "body contents", "hello: world\n", rec.Body.String())
+}
+
+type shoutingJsonCodec struct{}
+
+func (shoutingJsonCodec) MediaType() string    { return rsvp.SupportedMediaTypeJson }
+func (shoutingJsonCodec) ContentType() string  { return "application/json; charset=utf-8" }
+func (shoutingJsonCodec) Extensions() []string { return []string{"json"} }
+
+func (shoutingJsonCodec) CanEncode(res *rsvp.Body, cfg rsvp.Config) bool {
+	_, ok := res.Data.(string)
+	return ok
+}
+
+func (shoutingJsonCodec) Encode(w io.Writer, res *rsvp.Body, cfg rsvp.Config) error {
+	_, err := fmt.Fprintf(w, "%q\n", res.Data.(string)+"!")
+	return err
+}
+
+func TestCustomCodecOverridesBuiltin(t *testing.T) {
+	cfg := rsvp.Config{Codecs: []rsvp.Codec{shoutingJsonCodec{}}}
+	res := rsvp.Data("hello")
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", 200, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "body contents", `"hello!"`+"\n", rec.Body.String())
+}
+
+type greetingKey struct{}
+
+// greetingCodec renders the greeting stored in the context of the request.
+type greetingCodec struct{}
+
+func (greetingCodec) MediaType() string    { return "text/x-greeting" }
+func (greetingCodec) ContentType() string  { return "text/x-greeting" }
+func (greetingCodec) Extensions() []string { return nil }
+
+func (greetingCodec) CanEncode(res *rsvp.Body, cfg rsvp.Config) bool {
+	return true
+}
+
+func (greetingCodec) Encode(w io.Writer, res *rsvp.Body, cfg rsvp.Config) error {
+	_, err := fmt.Fprintf(w, "%s, %v", res.Context().Value(greetingKey{}), res.Data)
+	return err
+}
+
+func TestCustomCodecReceivesRequestContext(t *testing.T) {
+	cfg := rsvp.Config{Codecs: []rsvp.Codec{greetingCodec{}}}
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), greetingKey{}, "Hello"))
+	req.Header.Set("Accept", "text/x-greeting")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data("World"), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "body contents", "Hello, World", rec.Body.String())
+}
@@ -1,7 +1,9 @@
 package rsvp
 
 import (
+	"context"
 	html "html/template"
+	"log/slog"
 	text "text/template"
 )
 
@@ -15,6 +17,15 @@ type Config struct {
 	//
 	// If both HtmlTemplate and TextTemplate match [Body.TemplateName], HtmlTemplate takes precedence.
 	TextTemplate *text.Template
+	// ProblemTemplateName is used in place of [ResponseWriter.DefaultTemplateName] when [Body.Data] is a [Problem], so that problems may be rendered as HTML or plain text.
+	//
+	// It does not override [Body.TemplateName].
+	ProblemTemplateName string
+
+	// Buffered renders each response into a buffer before anything is written, and sets Content-Length.
+	//
+	// If rendering fails, the buffer is discarded and a negotiated 500 [Problem] is written instead, rather than a 200 followed by a partial body.
+	Buffered bool
 
 	// JsonPrefix is used to set [json.Encoder.SetIndent]
 	JsonPrefix string
@@ -24,4 +35,55 @@ type Config struct {
 	XmlPrefix string
 	// XmlIndent is used to set [xml.Encoder.Indent]
 	XmlIndent string
+	// XmlRoot names the root element of application/xml responses whose [Body.Data] has no element name of its own, such as slices, maps and strings. Defaults to [DefaultXmlRoot]. It may be overridden with [Body.XmlRoot].
+	XmlRoot string
+	// XmlOptions control the declaration, stylesheet and namespaces of application/xml. They may be overridden with [Body.XmlOptions].
+	XmlOptions XmlOptions
+
+	// CsvOptions control how text/csv and text/tab-separated-values are written. They may be overridden with [Body.CsvOptions].
+	CsvOptions CsvOptions
+
+	// Codecs registers additional media types. They are offered after the built-in media types, in the order given.
+	//
+	// If a Codec shares its media type with a built-in codec, it takes precedence when rendering.
+	Codecs []Codec
+	// MediaTypeQuality sets the server-side quality (between 0 and 1) of media types, which is multiplied with the weight the client gives them in the Accept header.
+	//
+	// Media types that are not present have a quality of 1. A quality of 0 means that the media type is only chosen via a URL path extension.
+	//
+	// For example, setting application/xml to 0.5 means that XML is only chosen for Accept: */* if nothing else is offered.
+	MediaTypeQuality map[string]float32
+	// ErrorMapper turns errors returned by a [HandlerFuncE] into a [Body]. Defaults to [MapError].
+	ErrorMapper ErrorMapper
+	// OnError is called by [Adapter] when a response could not be written, e.g. because a template failed or the client went away.
+	//
+	// If it is nil, errors are logged with [log.Printf].
+	OnError ErrorHook
+	// OnPanic is called by [Adapter] when a handler panics, or a panic occurs while its response is being written.
+	//
+	// If it is nil, the panic and its stack are logged with [log.Printf].
+	OnPanic PanicHook
+
+	// MaxMultipartMemory is passed to [http.Request.ParseMultipartForm] by [Decode]. Defaults to [DefaultMaxMultipartMemory].
+	MaxMultipartMemory int64
+	// ExcludeMediaTypes are never offered, even if a built-in codec or one of Codecs is able to render them.
+	ExcludeMediaTypes []string
+
+	// ExplainHeader is the name of a response header, e.g. "Rsvp-Explain", that is set to a condensed [Explanation] of how the response was negotiated. It is not set if ExplainHeader is empty.
+	ExplainHeader string
+
+	// Logger traces content negotiation and rendering at [slog.LevelDebug], with the context of the request. Nothing is logged if it is nil.
+	Logger *slog.Logger
+}
+
+// debugEnabled reports whether [Config.Logger] records debug messages, so that arguments to [Config.debug] that are costly to build may be skipped.
+func (cfg Config) debugEnabled(ctx context.Context) bool {
+	return cfg.Logger != nil && cfg.Logger.Enabled(ctx, slog.LevelDebug)
+}
+
+func (cfg Config) debug(ctx context.Context, msg string, args ...any) {
+	if !cfg.debugEnabled(ctx) {
+		return
+	}
+	cfg.Logger.DebugContext(ctx, msg, args...)
 }
@@ -1,59 +1,48 @@
 package rsvp
 
 import (
-	"io"
+	"context"
 	"iter"
+	"maps"
 	"net/http"
 	"path/filepath"
 	"slices"
 	"strings"
 
-	"github.com/Teajey/rsvp/internal/content"
-	"github.com/Teajey/rsvp/internal/dev"
+	"github.com/Teajey/rsvp/negotiate"
 )
 
 const (
 	SupportedMediaTypePlaintext string = "text/plain"
 	SupportedMediaTypeHtml      string = "text/html"
 	SupportedMediaTypeCsv       string = "text/csv"
+	SupportedMediaTypeTsv       string = "text/tab-separated-values"
 	SupportedMediaTypeBytes     string = "application/octet-stream"
 	SupportedMediaTypeJson      string = "application/json"
 	SupportedMediaTypeXml       string = "application/xml"
 )
 
-var mediaTypeToContentType = map[string]string{
-	// TODO: Why did I insist on specifying utf-8 here? There should be note. I think it might just be because it's inline with what net/http does
-	SupportedMediaTypePlaintext: "text/plain; charset=utf-8",
-	SupportedMediaTypeHtml:      "text/html; charset=utf-8",
-	SupportedMediaTypeCsv:       "text/csv; charset=utf-8",
-	SupportedMediaTypeBytes:     "application/octet-stream",
-	SupportedMediaTypeJson:      "application/json",
-	SupportedMediaTypeXml:       "application/xml",
-}
-
-var extToProposalMap = map[string]string{
-	"txt":  SupportedMediaTypePlaintext,
-	"html": SupportedMediaTypeHtml,
-	"htm":  SupportedMediaTypeHtml,
-	"csv":  SupportedMediaTypeCsv,
-	"json": SupportedMediaTypeJson,
-	"xml":  SupportedMediaTypeXml,
-	"bin":  SupportedMediaTypeBytes,
-}
-
-var extendedMediaTypes []string = nil
-
-type mediaTypeExtensionHandler = func(mediaType string, w io.Writer, res *Body) (bool, error)
-
-var mediaTypeExtensionHandlers []mediaTypeExtensionHandler = nil
-
-func (res *Body) determineSupported(cfg Config) []string {
+func (res *Body) determineSupported(ctx context.Context, cfg Config) []string {
 	supported := slices.Collect(res.MediaTypes(cfg))
-	dev.Log("supported %v", supported)
+	if res.predeterminedMediaType != "" {
+		cfg.debug(ctx, "overriding supported media types with the predetermined media type", "media_type", res.predeterminedMediaType)
+	}
+	cfg.debug(ctx, "determined supported media types", "supported", supported)
 
 	return supported
 }
 
+// suffixMediaType returns the media type of the structured syntax suffix of mediaType (https://www.rfc-editor.org/rfc/rfc6839), e.g. application/xml for application/rss+xml.
+func suffixMediaType(mediaType string) (string, bool) {
+	switch {
+	case strings.HasSuffix(mediaType, "+xml"):
+		return SupportedMediaTypeXml, true
+	case strings.HasSuffix(mediaType, "+json"):
+		return SupportedMediaTypeJson, true
+	}
+	return "", false
+}
+
 func determineExt(r *http.Request) string {
 	var ext string
 	if r.Method == http.MethodGet {
@@ -63,125 +52,204 @@ func determineExt(r *http.Request) string {
 	return ext
 }
 
-func (res *Body) determineMediaType(ext, accept string, supported []string) string {
-	mediaType := chooseMediaType(ext, supported, content.ParseAccept(accept))
-	dev.Log("mediaType %#v", mediaType)
-
-	return mediaType
-}
-
-func (res *Body) determineContentType(mediaType string, wh http.Header) {
-	contentType := mediaTypeToContentType[mediaType]
+func (res *Body) determineContentType(ctx context.Context, mediaType string, wh http.Header, cfg Config) {
+	contentType := res.contentType(mediaType, cfg)
 
-	dev.Log("Setting content-type to %#v", contentType)
+	cfg.debug(ctx, "setting content type", "media_type", mediaType, "content_type", contentType)
 	wh.Set("Content-Type", contentType)
 }
 
-// mediatype string m must be well-formed
-func splitMediaType(m string) (string, string) {
-	sp := strings.SplitN(m, "/", 2)
-	return sp[0], sp[1]
-}
+// negotiateResponse chooses the media type and status of the response to r. If x is not nil, each decision is recorded in it.
+func (res *Body) negotiateResponse(r *http.Request, cfg Config, x *Explanation) (mediaType string, status int) {
+	ctx := r.Context()
+	status = res.status()
+	accept := r.Header.Get("Accept")
+	header := negotiate.ParseAccept(accept)
+	ext := determineExt(r)
+	supported := res.determineSupported(ctx, cfg)
+	if x != nil {
+		x.Accept = header
+		x.Ext = ext
+		x.Supported = supported
+		defer func() {
+			x.MediaType = mediaType
+			x.Status = status
+			if !res.isBlank() {
+				x.ContentType = res.contentType(mediaType, cfg)
+			}
+		}()
+	}
+
+	mediaType = res.useVariant(res.chooseMediaType(ctx, ext, supported, header, cfg, x))
+	cfg.debug(ctx, "chose media type", "accept", accept, "ext", ext, "supported", supported, "chosen", mediaType)
 
-// mediatype string b must be well-formed
-func mediaTypesEqual(a string, b string) bool {
-	if strings.HasPrefix(b, "*/") {
-		return true
+	if res.isRedirect() {
+		return
 	}
 
-	b1, b2 := splitMediaType(b)
-	s := a
-	s1, s2 := splitMediaType(s)
+	if ext != "" && !res.keepStatus {
+		a, ok := cfg.extMediaType(ext)
+		if !ok || !slices.Contains(supported, a) {
+			cfg.debug(ctx, "extension is not supported", "ext", ext, "supported", supported)
+			status = http.StatusNotFound
+			if x != nil {
+				x.ExtUnsupported = true
+			}
+		}
+	}
 
-	if b1 == s1 {
-		return b2 == "*" || b2 == s2
+	if mediaType == "" {
+		if !res.keepStatus {
+			status = http.StatusNotAcceptable
+		}
+		if x != nil {
+			x.Fallback = true
+		}
+		mediaType = res.useVariant(res.chooseMediaType(ctx, ext, supported, negotiate.ParseAccept(""), cfg, x))
+		if mediaType == "" && len(supported) > 0 {
+			cfg.debug(ctx, "every supported media type has a server quality of 0, so using the first", "supported", supported)
+			mediaType = supported[0]
+		}
+		cfg.debug(ctx, "nothing acceptable was supported, so ignoring the Accept header", "accept", accept, "supported", supported, "chosen", mediaType, "status", status)
 	}
 
-	return false
+	return
 }
 
-func chooseMediaType(ext string, supported []string, accept iter.Seq[string]) string {
+func (res *Body) chooseMediaType(ctx context.Context, ext string, supported []string, accept negotiate.Header, cfg Config, x *Explanation) string {
+	extMatched := false
 	if ext != "" {
-		dev.Log("Checking extension: %#v", ext)
-		if a, ok := extToProposalMap[ext]; ok {
+		if a, ok := cfg.extMediaType(ext); ok {
+			if x != nil {
+				x.ExtMediaType = a
+			}
 			if slices.Contains(supported, a) {
-				dev.Log("Setting %#v as sole supported type", a)
+				cfg.debug(ctx, "extension narrows supported media types", "ext", ext, "media_type", a)
 				supported = []string{a}
+				extMatched = true
 			}
 		}
 	}
 
-	dev.Log("Checking accept list")
-	for a := range accept {
-		dev.Log("proposing %#v", a)
-		for _, s := range supported {
-			dev.Log("offering  %#v", s)
-			if mediaTypesEqual(s, a) {
-				return s
+	offers := make([]negotiate.Offer, 0, len(supported))
+	for _, s := range supported {
+		quality := res.quality(s, cfg)
+		if extMatched {
+			// The extension has already decided, so server quality no longer applies
+			quality = 1
+		}
+		for _, variant := range res.variants(s, cfg) {
+			offer := negotiate.Offer{Value: variant, Quality: quality}
+			if cfg.debugEnabled(ctx) {
+				cfg.debug(ctx, "offering media type", "media_type", variant, "quality", accept.Quality(variant), "server_quality", quality)
+			}
+			if x != nil {
+				x.compare(accept, offer)
 			}
+			offers = append(offers, offer)
+		}
+	}
+
+	offer, ok := accept.ChooseOffer(offers)
+	if !ok {
+		return ""
+	}
+
+	return offer.Value
+}
+
+// variantCodec may be implemented by a [Codec] whose media type has parameters that the client may choose between with the Accept header, e.g. text/csv;header=absent
+type variantCodec interface {
+	// variants returns the parameters of each variant (e.g. "header=absent"), starting with the default.
+	variants(res *Body, cfg Config) []string
+}
+
+// variants returns mediaType with the parameters of each of its variants, or only mediaType if it has none.
+//
+// The parameters declared by the Content-Type of mediaType (e.g. charset=utf-8) are included, so that they are matched against those of the Accept header.
+func (res *Body) variants(mediaType string, cfg Config) []string {
+	offer := mediaType
+	if declared := contentTypeParams(mediaType, cfg); len(declared) > 0 {
+		for _, k := range slices.Sorted(maps.Keys(declared)) {
+			offer += ";" + k + "=" + declared[k]
 		}
 	}
+	vc, ok := cfg.lookupEncoder(mediaType, res).(variantCodec)
+	if !ok {
+		return []string{offer}
+	}
+	params := vc.variants(res, cfg)
+	if len(params) == 0 {
+		return []string{offer}
+	}
+	variants := make([]string, len(params))
+	for i, p := range params {
+		variants[i] = offer + ";" + p
+	}
+	return variants
+}
+
+// contentTypeParams returns the parameters of the Content-Type of mediaType.
+func contentTypeParams(mediaType string, cfg Config) map[string]string {
+	contentType, ok := cfg.contentType(mediaType)
+	if !ok {
+		return nil
+	}
+	p, err := negotiate.ParseProposal(contentType)
+	if err != nil {
+		return nil
+	}
+	return p.Params
+}
+
+// useVariant returns the media type of the variant chosen by chooseMediaType, and keeps its parameters in res.mediaTypeParams for rendering.
+func (res *Body) useVariant(chosen string) string {
+	res.mediaTypeParams = nil
+	if !strings.Contains(chosen, ";") {
+		return chosen
+	}
+	p, err := negotiate.ParseProposal(chosen)
+	if err != nil {
+		return chosen
+	}
+	res.mediaTypeParams = p.Params
+	return p.Value
+}
 
-	return ""
+// contentType returns the Content-Type of mediaType, including the parameters of the negotiated variant.
+func (res *Body) contentType(mediaType string, cfg Config) string {
+	contentType, _ := cfg.contentType(mediaType)
+	declared := contentTypeParams(mediaType, cfg)
+	for _, k := range slices.Sorted(maps.Keys(res.mediaTypeParams)) {
+		if _, ok := declared[k]; ok {
+			continue
+		}
+		contentType += "; " + k + "=" + res.mediaTypeParams[k]
+	}
+	return contentType
 }
 
 // MediaTypes returns the sequence of media types (e.g. text/plain) in the order that this [Body] will propose.
 //
-// The order generally follows this pattern:
+// Each [Codec] that can encode this Body is offered in turn. The built-in codecs generally follow this pattern:
 //  1. Type-specific (Html wrapper, string, bytes)
-//  2. Generic structured (JSON, XML)
-//  3. Interface implementations (CSV)
+//  2. Generic structured (JSON, XML, and the newline-delimited JSON and JSON text sequences of slices and streams)
+//  3. Interface implementations and slices of structs (CSV, TSV, Markdown, the HTML and plain text tables of [Table], and the Atom and RSS of [Feed])
 //  4. Template-based (HTML template, text template)
+//
+// [Config.Codecs] are offered after the built-in codecs.
 func (res *Body) MediaTypes(cfg Config) iter.Seq[string] {
 	return func(yield func(string) bool) {
 		if res.predeterminedMediaType != "" {
-			dev.Log("Overriding media-types with %s", res.predeterminedMediaType)
 			yield(string(res.predeterminedMediaType))
 			return
 		}
 
-		switch res.Data.(type) {
-		case string:
-			if !yield(SupportedMediaTypePlaintext) {
-				return
-			}
-		case []byte:
-			if !yield(SupportedMediaTypeBytes) {
-				return
-			}
-		}
-
-		if !yield(SupportedMediaTypeJson) {
-			return
-		}
-
-		if !yield(SupportedMediaTypeXml) {
-			return
-		}
-
-		_, ok := res.Data.(Csv)
-		if ok {
-			if !yield(SupportedMediaTypeCsv) {
-				return
-			}
-		}
-
-		if res.TemplateName != "" {
-			if cfg.HtmlTemplate != nil && cfg.HtmlTemplate.Lookup(res.TemplateName) != nil {
-				if !yield(SupportedMediaTypeHtml) {
-					return
-				}
+		for c := range cfg.registry() {
+			if !c.CanEncode(res, cfg) {
+				continue
 			}
-
-			if cfg.TextTemplate != nil && cfg.TextTemplate.Lookup(res.TemplateName) != nil {
-				if !yield(SupportedMediaTypePlaintext) {
-					return
-				}
-			}
-		}
-
-		for _, mediaType := range extendedMediaTypes {
-			if !yield(mediaType) {
+			if !yield(c.MediaType()) {
 				return
 			}
 		}
@@ -1,9 +1,408 @@
 package rsvp
 
-import "encoding/csv"
+import (
+	"context"
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
 
-// Csv is used to provide rsvp with a way to render your type as text/csv.
+// Csv is used to provide rsvp with a way to render your type as text/csv and text/tab-separated-values.
+//
+// It takes precedence over [Table], and the automatic rendering of slices, arrays and [iter.Seq] of structs.
 type Csv interface {
 	// MarshalCsv will be called if the Accept header contains text/csv and it is matched, or the URL path ends with .csv
+	//
+	// The delimiter and line endings of w are already set according to [CsvOptions].
 	MarshalCsv(w *csv.Writer) error
 }
+
+// CsvOptionsMarshaler is like [Csv], but is also given the negotiated [CsvOptions], so that it may leave out its header row. It takes precedence over Csv.
+type CsvOptionsMarshaler interface {
+	MarshalCsvOptions(w *csv.Writer, opts CsvOptions) error
+}
+
+// CsvOptions control how CSV and tab-separated values are written. See [Config.CsvOptions] and [Body.CsvOptions].
+type CsvOptions struct {
+	// Comma is the field delimiter of text/csv. Defaults to ','. It does not affect text/tab-separated-values, which is always delimited by tabs.
+	Comma rune
+	// OmitHeader leaves out the header row.
+	//
+	// For text/csv, it may be overridden by the client with the header parameter of https://www.rfc-editor.org/rfc/rfc4180, e.g. Accept: text/csv;header=absent
+	// This is only possible if the header row is written by rsvp, or [CsvOptionsMarshaler] is implemented.
+	OmitHeader bool
+	// UseCRLF ends rows with \r\n, as specified by RFC 4180, rather than \n.
+	UseCRLF bool
+	// BOM writes a UTF-8 byte order mark before the first row, which helps Excel to detect the encoding.
+	BOM bool
+}
+
+// csvOptions resolves the options of res, which are [Config.CsvOptions] unless overridden by [Body.CsvOptions], and then by the negotiated header parameter.
+func (res *Body) csvOptions(cfg Config) CsvOptions {
+	opts := cfg.CsvOptions
+	if res.csvOpts != nil {
+		opts = *res.csvOpts
+	}
+	switch res.mediaTypeParams["header"] {
+	case "absent":
+		opts.OmitHeader = true
+	case "present":
+		opts.OmitHeader = false
+	}
+	return opts
+}
+
+// CsvOptions overrides [Config.CsvOptions] for this Body only.
+func (res Body) CsvOptions(opts CsvOptions) Body {
+	res.csvOpts = &opts
+	return res
+}
+
+type csvCodec struct{}
+
+func (csvCodec) MediaType() string    { return SupportedMediaTypeCsv }
+func (csvCodec) ContentType() string  { return "text/csv; charset=utf-8" }
+func (csvCodec) Extensions() []string { return []string{"csv"} }
+
+func (csvCodec) CanEncode(res *Body, cfg Config) bool {
+	return canEncodeCsv(res.Data)
+}
+
+// variants offers both values of the RFC 4180 header parameter if the header row can be controlled, starting with the default.
+func (csvCodec) variants(res *Body, cfg Config) []string {
+	switch res.Data.(type) {
+	case CsvOptionsMarshaler:
+	case Csv:
+		return nil
+	case Table:
+	default:
+		if !canMarshalCsv(res.Data) {
+			return nil
+		}
+	}
+	if res.csvOptions(cfg).OmitHeader {
+		return []string{"header=absent", "header=present"}
+	}
+	return []string{"header=present", "header=absent"}
+}
+
+func (csvCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	opts := res.csvOptions(cfg)
+	if opts.Comma == 0 {
+		opts.Comma = ','
+	}
+	err := encodeCsv(res.Context(), w, res.Data, opts)
+	if err != nil {
+		return fmt.Errorf("rendering data as CSV: %w", err)
+	}
+	return nil
+}
+
+type tsvCodec struct{}
+
+func (tsvCodec) MediaType() string    { return SupportedMediaTypeTsv }
+func (tsvCodec) ContentType() string  { return "text/tab-separated-values; charset=utf-8" }
+func (tsvCodec) Extensions() []string { return []string{"tsv"} }
+
+func (tsvCodec) CanEncode(res *Body, cfg Config) bool {
+	return canEncodeCsv(res.Data)
+}
+
+func (tsvCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	opts := res.csvOptions(cfg)
+	opts.Comma = '\t'
+	err := encodeCsv(res.Context(), w, res.Data, opts)
+	if err != nil {
+		return fmt.Errorf("rendering data as tab-separated values: %w", err)
+	}
+	return nil
+}
+
+func canEncodeCsv(data any) bool {
+	switch data.(type) {
+	case Csv, CsvOptionsMarshaler, Table:
+		return true
+	}
+	return canMarshalCsv(data)
+}
+
+func encodeCsv(ctx context.Context, w io.Writer, data any, opts CsvOptions) error {
+	if opts.BOM {
+		_, err := io.WriteString(w, "\uFEFF")
+		if err != nil {
+			return err
+		}
+	}
+
+	wr := csv.NewWriter(w)
+	wr.Comma = opts.Comma
+	wr.UseCRLF = opts.UseCRLF
+
+	var err error
+	switch data := data.(type) {
+	case CsvOptionsMarshaler:
+		err = data.MarshalCsvOptions(wr, opts)
+	case Csv:
+		err = data.MarshalCsv(wr)
+	case Table:
+		err = marshalTable(wr, data, opts)
+	default:
+		err = marshalCsv(ctx, wr, data, opts, func() error {
+			wr.Flush()
+			if err := wr.Error(); err != nil {
+				return err
+			}
+			return flushWriter(w)
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	wr.Flush()
+	return wr.Error()
+}
+
+// csvColumn is a field of a struct that is rendered as a CSV column.
+type csvColumn struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+type csvColumnsResult struct {
+	columns []csvColumn
+	ok      bool
+}
+
+// csvColumnsCache maps struct types to their csvColumnsResult.
+var csvColumnsCache sync.Map
+
+// csvColumns returns the columns of the struct type t, as named by their `csv` tags.
+//
+// ok is false if any of the fields cannot be rendered as a CSV cell.
+func csvColumns(t reflect.Type) (columns []csvColumn, ok bool) {
+	if cached, found := csvColumnsCache.Load(t); found {
+		result := cached.(csvColumnsResult)
+		return result.columns, result.ok
+	}
+
+	columns, ok = appendCsvColumns(nil, t, nil)
+	csvColumnsCache.Store(t, csvColumnsResult{columns, ok})
+	return columns, ok
+}
+
+func appendCsvColumns(columns []csvColumn, t reflect.Type, index []int) ([]csvColumn, bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("csv")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldIndex := append(index[:len(index):len(index)], i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct && !isTextMarshaler(fieldType) {
+			var ok bool
+			columns, ok = appendCsvColumns(columns, fieldType, fieldIndex)
+			if !ok {
+				return nil, false
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if !csvCellSupported(field.Type) {
+			return nil, false
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{
+			name:      name,
+			index:     fieldIndex,
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+	return columns, true
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+func isTextMarshaler(t reflect.Type) bool {
+	return t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
+
+func csvCellSupported(t reflect.Type) bool {
+	if isTextMarshaler(t) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return csvCellSupported(t.Elem())
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// csvRows returns the struct type of the rows of data, if data is a slice, array or stream of structs or pointers to structs.
+func csvRows(data any) (structType reflect.Type, ok bool) {
+	v := reflect.ValueOf(data)
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		structType = v.Type().Elem()
+	default:
+		s, ok := asStream(data)
+		if !ok {
+			return nil, false
+		}
+		structType = s.elem
+	}
+
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct || isTextMarshaler(structType) {
+		return nil, false
+	}
+
+	return structType, true
+}
+
+func canMarshalCsv(data any) bool {
+	structType, ok := csvRows(data)
+	if !ok {
+		return false
+	}
+	columns, ok := csvColumns(structType)
+	return ok && len(columns) > 0
+}
+
+// marshalCsv writes a header row of column names, unless it is omitted by opts, followed by a row for each struct in data.
+//
+// If data is a stream, flush is called periodically and ctx stops it.
+func marshalCsv(ctx context.Context, w *csv.Writer, data any, opts CsvOptions, flush func() error) error {
+	structType, ok := csvRows(data)
+	if !ok {
+		return fmt.Errorf("cannot render %T as CSV", data)
+	}
+	columns, ok := csvColumns(structType)
+	if !ok {
+		return fmt.Errorf("cannot render %T as CSV because some of its fields are not supported", data)
+	}
+
+	record := make([]string, len(columns))
+	if !opts.OmitHeader {
+		for i, c := range columns {
+			record[i] = c.name
+		}
+		err := w.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	writeRow := func(row reflect.Value) error {
+		var err error
+		for i, c := range columns {
+			record[i], err = csvCell(row, c)
+			if err != nil {
+				return fmt.Errorf("column %s: %w", c.name, err)
+			}
+		}
+		return w.Write(record)
+	}
+
+	if s, ok := asStream(data); ok {
+		return s.each(ctx, flush, writeRow)
+	}
+	v := reflect.ValueOf(data)
+	for i := range v.Len() {
+		err := writeRow(v.Index(i))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func csvCell(row reflect.Value, c csvColumn) (string, error) {
+	v := row
+	for _, i := range c.index {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return "", nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+
+	if c.omitEmpty && v.IsZero() {
+		return "", nil
+	}
+
+	return csvValue(v)
+}
+
+func csvValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			return string(text), err
+		}
+		return csvValue(v.Elem())
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	if reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		text, err := ptr.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("cannot render %s as a CSV cell", v.Type())
+}
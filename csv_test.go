@@ -0,0 +1,240 @@
+package rsvp_test
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+type csvLevel int
+
+func (l *csvLevel) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("*", int(*l))), nil
+}
+
+type csvAudit struct {
+	CreatedAt time.Time  `csv:"created_at"`
+	DeletedAt *time.Time `csv:"deleted_at"`
+}
+
+type csvUser struct {
+	ID       int    `csv:"id"`
+	Name     string `csv:"name"`
+	Nickname *string
+	Admin    bool     `csv:"admin"`
+	Score    float64  `csv:"score,omitempty"`
+	Level    csvLevel `csv:"level"`
+	Password string   `csv:"-"`
+	internal string
+	csvAudit
+}
+
+func renderCsv(t *testing.T, data any) string {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/users.csv", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data(data), rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+	assert.Eq(t, "status", 200, rec.Code)
+	assert.True(t, "content type is CSV", strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv; charset=utf-8"))
+
+	return rec.Body.String()
+}
+
+func TestCsvSliceOfStructs(t *testing.T) {
+	created := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	nickname := "Al, the great"
+	users := []csvUser{
+		{ID: 1, Name: "Alice", Nickname: &nickname, Admin: true, Score: 9.5, Level: 3, Password: "secret", internal: "x", csvAudit: csvAudit{CreatedAt: created}},
+		{ID: 2, Name: "Bob", Level: 1, csvAudit: csvAudit{CreatedAt: created, DeletedAt: &created}},
+	}
+
+	expected := `id,name,Nickname,admin,score,level,created_at,deleted_at
+1,Alice,"Al, the great",true,9.5,***,2024-04-01T12:00:00Z,
+2,Bob,,false,,*,2024-04-01T12:00:00Z,2024-04-01T12:00:00Z
+`
+	assert.Eq(t, "body", expected, renderCsv(t, users))
+}
+
+type csvRow struct {
+	Name string
+}
+
+type csvPointerEmbed struct {
+	*csvRow
+	Count uint `csv:"count"`
+}
+
+func TestCsvNilEmbeddedPointer(t *testing.T) {
+	rows := []*csvPointerEmbed{{csvRow: &csvRow{Name: "a"}, Count: 1}, {Count: 2}, nil}
+
+	assert.Eq(t, "body", "Name,count\na,1\n,2\n,\n", renderCsv(t, rows))
+}
+
+func TestCsvArray(t *testing.T) {
+	rows := [2]csvRow{{Name: "a"}, {Name: "b"}}
+
+	assert.Eq(t, "body", "Name\na\nb\n", renderCsv(t, rows))
+}
+
+func TestCsvSeq(t *testing.T) {
+	rows := slices.Values([]csvRow{{Name: "a"}, {Name: "b"}})
+
+	assert.Eq(t, "body", "Name\na\nb\n", renderCsv(t, rows))
+}
+
+func TestCsvEmptySlice(t *testing.T) {
+	assert.Eq(t, "body", "Name\n", renderCsv(t, []csvRow{}))
+}
+
+type csvRows []csvRow
+
+func (rows csvRows) MarshalCsv(w *csv.Writer) error {
+	return w.Write([]string{"custom"})
+}
+
+func TestCsvInterfaceTakesPrecedence(t *testing.T) {
+	assert.Eq(t, "body", "custom\n", renderCsv(t, csvRows{{Name: "a"}}))
+}
+
+func TestCsvMediaTypes(t *testing.T) {
+	cfg := rsvp.Config{}
+
+	offered := func(data any) bool {
+		res := rsvp.Data(data)
+		return slices.Contains(slices.Collect(res.MediaTypes(cfg)), rsvp.SupportedMediaTypeCsv)
+	}
+
+	assert.True(t, "slice of structs", offered([]csvRow{}))
+	assert.True(t, "slice of struct pointers", offered([]*csvRow{}))
+	assert.True(t, "seq of structs", offered(slices.Values([]csvRow{})))
+	assert.True(t, "single struct", !offered(csvRow{}))
+	assert.True(t, "slice of strings", !offered([]string{}))
+	assert.True(t, "slice of times", !offered([]time.Time{}))
+	assert.True(t, "unsupported field", !offered([]struct{ Tags []string }{}))
+	assert.True(t, "no columns", !offered([]struct {
+		Secret string `csv:"-"`
+	}{}))
+}
+
+func TestCsvHeaderParameter(t *testing.T) {
+	rows := []csvRow{{Name: "a"}}
+
+	for _, tc := range []struct {
+		accept      string
+		cfg         rsvp.Config
+		contentType string
+		body        string
+	}{
+		{"text/csv", rsvp.Config{}, "text/csv; charset=utf-8; header=present", "Name\na\n"},
+		{"text/csv;header=absent", rsvp.Config{}, "text/csv; charset=utf-8; header=absent", "a\n"},
+		{"text/csv", rsvp.Config{CsvOptions: rsvp.CsvOptions{OmitHeader: true}}, "text/csv; charset=utf-8; header=absent", "a\n"},
+		{"text/csv;header=present", rsvp.Config{CsvOptions: rsvp.CsvOptions{OmitHeader: true}}, "text/csv; charset=utf-8; header=present", "Name\na\n"},
+		{"text/csv;header=absent;q=0.5, text/csv;header=present", rsvp.Config{}, "text/csv; charset=utf-8; header=present", "Name\na\n"},
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", tc.accept)
+		rec := httptest.NewRecorder()
+
+		err := makeHandler(rsvp.Data(rows), tc.cfg)(rec, req)
+		assert.FatalErr(t, "handler", err)
+
+		assert.Eq(t, tc.accept+" status", 200, rec.Code)
+		assert.Eq(t, tc.accept+" content type", tc.contentType, rec.Header().Get("Content-Type"))
+		assert.Eq(t, tc.accept+" body", tc.body, rec.Body.String())
+	}
+}
+
+func TestCsvHeaderParameterNotAcceptableForCsvInterface(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/csv;header=absent")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data(csvRows{}), rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "status", 406, rec.Code)
+}
+
+type csvOptionsRows []csvRow
+
+func (rows csvOptionsRows) MarshalCsvOptions(w *csv.Writer, opts rsvp.CsvOptions) error {
+	if !opts.OmitHeader {
+		err := w.Write([]string{"NAME"})
+		if err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		err := w.Write([]string{row.Name})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestCsvOptionsMarshaler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/csv;header=absent")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data(csvOptionsRows{{Name: "a"}}), rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "body", "a\n", rec.Body.String())
+}
+
+func TestCsvDialect(t *testing.T) {
+	rows := []csvRow{{Name: "a;b"}, {Name: "c"}}
+	cfg := rsvp.Config{CsvOptions: rsvp.CsvOptions{Comma: ';', UseCRLF: true, BOM: true}}
+
+	req := httptest.NewRequest("GET", "/rows.csv", nil)
+	rec := httptest.NewRecorder()
+	err := makeHandler(rsvp.Data(rows), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "body", "\uFEFFName\r\n\"a;b\"\r\nc\r\n", rec.Body.String())
+}
+
+func TestCsvBodyOptionsOverrideConfig(t *testing.T) {
+	rows := []csvRow{{Name: "a"}}
+	cfg := rsvp.Config{CsvOptions: rsvp.CsvOptions{BOM: true}}
+
+	req := httptest.NewRequest("GET", "/rows.csv", nil)
+	rec := httptest.NewRecorder()
+	err := makeHandler(rsvp.Data(rows).CsvOptions(rsvp.CsvOptions{OmitHeader: true}), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "content type", "text/csv; charset=utf-8; header=absent", rec.Header().Get("Content-Type"))
+	assert.Eq(t, "body", "a\n", rec.Body.String())
+}
+
+func TestTsv(t *testing.T) {
+	rows := []csvRow{{Name: "a"}, {Name: "b"}}
+	cfg := rsvp.Config{CsvOptions: rsvp.CsvOptions{Comma: ';'}}
+
+	req := httptest.NewRequest("GET", "/rows.tsv", nil)
+	rec := httptest.NewRecorder()
+	err := makeHandler(rsvp.Data([]csvUser{{ID: 1, Name: "Alice"}}), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "status", 200, rec.Code)
+	assert.Eq(t, "content type", "text/tab-separated-values; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Eq(t, "body", "id\tname\tNickname\tadmin\tscore\tlevel\tcreated_at\tdeleted_at\n1\tAlice\t\tfalse\t\t\t0001-01-01T00:00:00Z\t\n", rec.Body.String())
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/tab-separated-values")
+	rec = httptest.NewRecorder()
+	err = makeHandler(rsvp.Data(rows), rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "body", "Name\na\nb\n", rec.Body.String())
+}
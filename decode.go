@@ -0,0 +1,234 @@
+package rsvp
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"iter"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	SupportedMediaTypeForm      string = "application/x-www-form-urlencoded"
+	SupportedMediaTypeMultipart string = "multipart/form-data"
+)
+
+// DefaultMaxMultipartMemory is passed to [http.Request.ParseMultipartForm] when [Config.MaxMultipartMemory] is not set.
+const DefaultMaxMultipartMemory int64 = 32 << 20
+
+// Decoder may be implemented by a [Codec] so that [Decode] can read request bodies of the Codec's media type.
+type Decoder interface {
+	// Decode reads the body of r into v.
+	Decode(r *http.Request, v any, cfg Config) error
+}
+
+// UnsupportedMediaTypeError is returned by [Decode] when no [Decoder] is registered for the Content-Type of a request.
+type UnsupportedMediaTypeError struct {
+	// MediaType is the media type of the request's Content-Type. It is empty if Content-Type was not set.
+	MediaType string
+	// Supported lists the media types that could have been decoded.
+	Supported []string
+	// Method is the method of the request.
+	Method string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	if e.MediaType == "" {
+		return fmt.Sprintf("missing Content-Type; expected one of %s", strings.Join(e.Supported, ", "))
+	}
+	return fmt.Sprintf("unsupported Content-Type %s; expected one of %s", e.MediaType, strings.Join(e.Supported, ", "))
+}
+
+// Body returns a 415 Unsupported Media Type response describing this error.
+//
+// The Accept-Post or Accept-Patch header is set on w for POST and PATCH requests respectively, as per https://www.rfc-editor.org/rfc/rfc9110#status.415
+func (e *UnsupportedMediaTypeError) Body(w ResponseWriter) Body {
+	switch e.Method {
+	case http.MethodPost:
+		w.Header().Set("Accept-Post", strings.Join(e.Supported, ", "))
+	case http.MethodPatch:
+		w.Header().Set("Accept-Patch", strings.Join(e.Supported, ", "))
+	}
+	return Data(e.Error()).StatusUnsupportedMediaType()
+}
+
+// mediaTypeDecoder is a [Decoder] of a single media type, which is not necessarily a [Codec].
+type mediaTypeDecoder interface {
+	Decoder
+	MediaType() string
+}
+
+// builtinDecoders read request bodies of media types that are never rendered, so they are not [Codec]s. It must not be modified.
+var builtinDecoders = []mediaTypeDecoder{
+	formDecoder{},
+	multipartDecoder{},
+}
+
+// decoders yields every decoder available to cfg: the built-in codecs that are a [Decoder], builtinDecoders, and then the [Config.Codecs] that are a Decoder.
+func (cfg Config) decoders() iter.Seq[mediaTypeDecoder] {
+	return func(yield func(mediaTypeDecoder) bool) {
+		for _, decoders := range [3][]mediaTypeDecoder{codecDecoders(builtinCodecs), builtinDecoders, codecDecoders(cfg.Codecs)} {
+			for _, d := range decoders {
+				if slices.Contains(cfg.ExcludeMediaTypes, d.MediaType()) {
+					continue
+				}
+				if !yield(d) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// codecDecoders returns the codecs that are a [Decoder].
+func codecDecoders(codecs []Codec) []mediaTypeDecoder {
+	var decoders []mediaTypeDecoder
+	for _, c := range codecs {
+		if d, ok := c.(mediaTypeDecoder); ok {
+			decoders = append(decoders, d)
+		}
+	}
+	return decoders
+}
+
+// decodableMediaTypes lists the media types of every [Decoder] available to cfg, without duplicates.
+func (cfg Config) decodableMediaTypes() []string {
+	var mediaTypes []string
+	for d := range cfg.decoders() {
+		if !slices.Contains(mediaTypes, d.MediaType()) {
+			mediaTypes = append(mediaTypes, d.MediaType())
+		}
+	}
+	return mediaTypes
+}
+
+// lookupDecoder finds the last registered [Decoder] for mediaType, so that [Config.Codecs] may override the built-in decoders.
+func (cfg Config) lookupDecoder(mediaType string) Decoder {
+	var found Decoder
+	for d := range cfg.decoders() {
+		if d.MediaType() == mediaType {
+			found = d
+		}
+	}
+	return found
+}
+
+// Decode reads the body of r into v, choosing a [Decoder] by the request's Content-Type header.
+//
+// The built-in decoders are:
+//   - application/json and application/xml, which decode into anything that [json.Unmarshal] and [xml.Unmarshal] can.
+//   - application/x-www-form-urlencoded, which decodes into *[url.Values], or a pointer to a struct with `form` tags.
+//   - multipart/form-data, which decodes into *[url.Values], *[multipart.Form], or a pointer to a struct with `form` tags.
+//
+// If v implements [Validator], it is validated after decoding. A [*ValidationError] is returned if a form could not be bound to v, or if v is invalid.
+//
+// If the Content-Type is missing or is not supported, an [*UnsupportedMediaTypeError] is returned, which can be turned into a 415 response with [UnsupportedMediaTypeError.Body].
+func Decode(r *http.Request, cfg Config, v any) error {
+	contentType := r.Header.Get("Content-Type")
+	mediaType := strings.ToLower(contentTypeExtractMediaType(contentType))
+	cfg.debug(r.Context(), "decoding request body", "media_type", mediaType)
+
+	var decoder Decoder
+	if mediaType != "" {
+		decoder = cfg.lookupDecoder(mediaType)
+	}
+	if decoder == nil {
+		return &UnsupportedMediaTypeError{
+			MediaType: mediaType,
+			Supported: cfg.decodableMediaTypes(),
+			Method:    r.Method,
+		}
+	}
+
+	err := decoder.Decode(r, v, cfg)
+	var verr *ValidationError
+	if err != nil && !errors.As(err, &verr) {
+		return err
+	}
+
+	if validator, ok := v.(Validator); ok {
+		if verr == nil {
+			verr = &ValidationError{Values: r.PostForm}
+		}
+		validator.Validate(verr)
+	}
+
+	if verr != nil && len(verr.Fields) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+func (jsonCodec) Decode(r *http.Request, v any, cfg Config) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("decoding JSON request body: %w", err)
+	}
+	return nil
+}
+
+func (xmlCodec) Decode(r *http.Request, v any, cfg Config) error {
+	err := xml.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("decoding XML request body: %w", err)
+	}
+	return nil
+}
+
+type formDecoder struct{}
+
+func (formDecoder) MediaType() string { return SupportedMediaTypeForm }
+
+func (formDecoder) Decode(r *http.Request, v any, cfg Config) error {
+	err := r.ParseForm()
+	if err != nil {
+		return fmt.Errorf("decoding form request body: %w", err)
+	}
+
+	return decodeForm(r.PostForm, nil, v)
+}
+
+type multipartDecoder struct{}
+
+func (multipartDecoder) MediaType() string { return SupportedMediaTypeMultipart }
+
+func (multipartDecoder) Decode(r *http.Request, v any, cfg Config) error {
+	maxMemory := cfg.MaxMultipartMemory
+	if maxMemory == 0 {
+		maxMemory = DefaultMaxMultipartMemory
+	}
+
+	err := r.ParseMultipartForm(maxMemory)
+	if err != nil {
+		return fmt.Errorf("decoding multipart request body: %w", err)
+	}
+
+	return decodeForm(r.MultipartForm.Value, r.MultipartForm, v)
+}
+
+func decodeForm(values url.Values, form *multipart.Form, v any) error {
+	switch v := v.(type) {
+	case *url.Values:
+		*v = values
+	case *map[string][]string:
+		*v = values
+	case *multipart.Form:
+		if form == nil {
+			return fmt.Errorf("cannot decode a %s request body into *multipart.Form", SupportedMediaTypeForm)
+		}
+		*v = *form
+	default:
+		var files map[string][]*multipart.FileHeader
+		if form != nil {
+			files = form.File
+		}
+		return bindForm(values, files, v)
+	}
+	return nil
+}
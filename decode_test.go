@@ -0,0 +1,140 @@
+package rsvp_test
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+type decodedUser struct {
+	Name  string `json:"name" xml:"name"`
+	Email string `json:"email" xml:"email"`
+}
+
+func TestDecodeJson(t *testing.T) {
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"Tea","email":"tea@example.com"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	var user decodedUser
+	err := rsvp.Decode(req, rsvp.Config{}, &user)
+	assert.FatalErr(t, "decode", err)
+	assert.Eq(t, "name", "Tea", user.Name)
+	assert.Eq(t, "email", "tea@example.com", user.Email)
+}
+
+func TestDecodeXml(t *testing.T) {
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`<user><name>Tea</name><email>tea@example.com</email></user>`))
+	req.Header.Set("Content-Type", "application/xml")
+
+	var user decodedUser
+	err := rsvp.Decode(req, rsvp.Config{}, &user)
+	assert.FatalErr(t, "decode", err)
+	assert.Eq(t, "name", "Tea", user.Name)
+	assert.Eq(t, "email", "tea@example.com", user.Email)
+}
+
+func TestDecodeForm(t *testing.T) {
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`name=Tea&email=tea%40example.com`))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var values url.Values
+	err := rsvp.Decode(req, rsvp.Config{}, &values)
+	assert.FatalErr(t, "decode", err)
+	assert.Eq(t, "name", "Tea", values.Get("name"))
+	assert.Eq(t, "email", "tea@example.com", values.Get("email"))
+}
+
+func TestDecodeMultipart(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	assert.FatalErr(t, "write field", mw.WriteField("name", "Tea"))
+	fw, err := mw.CreateFormFile("avatar", "tea.png")
+	assert.FatalErr(t, "create file", err)
+	_, err = fw.Write([]byte{0x89, 0x50, 0x4e, 0x47})
+	assert.FatalErr(t, "write file", err)
+	assert.FatalErr(t, "close", mw.Close())
+
+	req := httptest.NewRequest("POST", "/users", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var form multipart.Form
+	err = rsvp.Decode(req, rsvp.Config{}, &form)
+	assert.FatalErr(t, "decode", err)
+	assert.Eq(t, "name", "Tea", form.Value["name"][0])
+	assert.Eq(t, "file name", "tea.png", form.File["avatar"][0].Filename)
+}
+
+func TestDecodeUnsupported(t *testing.T) {
+	handler := rsvp.NewAdapter(rsvp.Config{}).AdaptFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		var user decodedUser
+		err := rsvp.Decode(r, rsvp.Config{}, &user)
+		var unsupported *rsvp.UnsupportedMediaTypeError
+		if errors.As(err, &unsupported) {
+			return unsupported.Body(w)
+		}
+		return rsvp.Data(user)
+	})
+
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`name: Tea`))
+	req.Header.Set("Content-Type", "application/yaml")
+	req.Header.Set("Accept", "text/plain")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusUnsupportedMediaType, resp.StatusCode)
+	assert.Eq(t, "Accept-Post", "application/json, application/xml, application/x-www-form-urlencoded, multipart/form-data", resp.Header.Get("Accept-Post"))
+	assert.Eq(t, "body contents", "unsupported Content-Type application/yaml; expected one of application/json, application/xml, application/x-www-form-urlencoded, multipart/form-data", rec.Body.String())
+}
+
+func TestDecodeMissingContentTypePatch(t *testing.T) {
+	req := httptest.NewRequest("PATCH", "/users/1", strings.NewReader(`{}`))
+
+	var user decodedUser
+	err := rsvp.Decode(req, rsvp.Config{}, &user)
+	var unsupported *rsvp.UnsupportedMediaTypeError
+	assert.FatalErrAs(t, "decode", err, &unsupported)
+	assert.Eq(t, "media type", "", unsupported.MediaType)
+
+	rec := httptest.NewRecorder()
+	err = rsvp.Write(rec, req, rsvp.Config{}, rsvp.HandlerFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return unsupported.Body(w)
+	}))
+	assert.FatalErr(t, "write", err)
+	assert.Eq(t, "Status code", http.StatusUnsupportedMediaType, rec.Code)
+	assert.True(t, "Accept-Patch is set", rec.Result().Header.Get("Accept-Patch") != "")
+}
+
+func TestDecodeMalformedJson(t *testing.T) {
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":`))
+	req.Header.Set("Content-Type", "application/json")
+
+	var user decodedUser
+	err := rsvp.Decode(req, rsvp.Config{}, &user)
+	var unsupported *rsvp.UnsupportedMediaTypeError
+	assert.True(t, "error is not unsupported", err != nil && !errors.As(err, &unsupported))
+}
+
+func TestFormIsNotARecognisedResponseContentType(t *testing.T) {
+	res := rsvp.Body{Data: map[string]string{"name": "Tea"}}
+	req := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusOK, resp.StatusCode)
+	assert.Eq(t, "body contents", `{"name":"Tea"}`+"\n", rec.Body.String())
+	assert.SlicesEq(t, "media types", []string{rsvp.SupportedMediaTypeJson, rsvp.SupportedMediaTypeXml}, slices.Collect(res.MediaTypes(rsvp.Config{})))
+}
@@ -0,0 +1,194 @@
+package rsvp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	text "text/template"
+)
+
+// ErrorMapper turns an error returned by a [HandlerFuncE] into a [Body], which is then negotiated like any other.
+type ErrorMapper func(w ResponseWriter, r *http.Request, err error) Body
+
+// MapError is the default [ErrorMapper]. It checks err with [errors.As] in this order:
+//   - [Problem] is rendered as-is, except that a zero [Problem.Status] is rendered as 500.
+//   - [*ValidationError] and [*UnsupportedMediaTypeError] are rendered with their Body methods.
+//   - [StatusCoder] is rendered as a [Problem] with the given status. The error message is only used as [Problem.Detail] for statuses below 500.
+//
+// Any other error is rendered as a 500 [Problem] that reveals nothing about err.
+func MapError(w ResponseWriter, r *http.Request, err error) Body {
+	if problem, ok := findProblem(err); ok {
+		if problem.Status == 0 {
+			problem.Status = http.StatusInternalServerError
+		}
+		return problem.Body()
+	}
+
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		return verr.Body()
+	}
+
+	var unsupported *UnsupportedMediaTypeError
+	if errors.As(err, &unsupported) {
+		return unsupported.Body(w)
+	}
+
+	var statusCoder StatusCoder
+	if errors.As(err, &statusCoder) {
+		status := statusCoder.StatusCode()
+		if status < http.StatusInternalServerError {
+			return NewProblem(status, err.Error()).Body()
+		}
+		return NewProblem(status, "").Body()
+	}
+
+	return NewProblem(http.StatusInternalServerError, "").Body()
+}
+
+// findProblem finds a [Problem] or [*Problem] in the tree of err.
+func findProblem(err error) (Problem, bool) {
+	var problem Problem
+	if errors.As(err, &problem) {
+		return problem, true
+	}
+
+	var problemPtr *Problem
+	if errors.As(err, &problemPtr) && problemPtr != nil {
+		return *problemPtr, true
+	}
+
+	return Problem{}, false
+}
+
+func (cfg Config) mapError(w ResponseWriter, r *http.Request, err error) Body {
+	cfg.debug(r.Context(), "mapping handler error", "error", err)
+	if cfg.ErrorMapper != nil {
+		return cfg.ErrorMapper(w, r, err)
+	}
+	return MapError(w, r, err)
+}
+
+// WriteErrorKind classifies a [WriteError].
+type WriteErrorKind int
+
+const (
+	// WriteErrorEncoding means that a [Codec] failed to encode [Body.Data].
+	WriteErrorEncoding WriteErrorKind = iota
+	// WriteErrorTemplate means that [Config.HtmlTemplate] or [Config.TextTemplate] failed to execute.
+	WriteErrorTemplate
+	// WriteErrorClientDisconnect means that the response could not be written to the connection, usually because the client has gone away.
+	WriteErrorClientDisconnect
+	// WriteErrorUnsupportedMediaType means that no [Codec] is able to render [Body.Data] as the negotiated media type.
+	WriteErrorUnsupportedMediaType
+	// WriteErrorStream means that [Body.Data] is an [iter.Seq2] that yielded an error partway through being rendered.
+	WriteErrorStream
+	// WriteErrorTimeout means that the deadline of the request's context passed while the response was being rendered, e.g. because of [http.TimeoutHandler].
+	WriteErrorTimeout
+)
+
+func (k WriteErrorKind) String() string {
+	switch k {
+	case WriteErrorEncoding:
+		return "encoding"
+	case WriteErrorTemplate:
+		return "template"
+	case WriteErrorClientDisconnect:
+		return "client disconnect"
+	case WriteErrorUnsupportedMediaType:
+		return "unsupported media type"
+	case WriteErrorStream:
+		return "stream"
+	case WriteErrorTimeout:
+		return "timeout"
+	default:
+		return fmt.Sprintf("WriteErrorKind(%d)", int(k))
+	}
+}
+
+// WriteError is returned by [Write] when a response could not be written. See [Config.OnError].
+type WriteError struct {
+	Kind WriteErrorKind
+	// MediaType is the negotiated media type of the response.
+	MediaType string
+	// Status is the status code of the response that failed. If [Config.Buffered] is set, a 500 may have been written in its place.
+	Status int
+	Err    error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("%s error writing %d %s response: %s", e.Kind, e.Status, e.MediaType, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorHook receives errors that occur while an [Adapter] is writing a response. See [Config.OnError].
+type ErrorHook func(r *http.Request, err *WriteError)
+
+func (cfg Config) onError(r *http.Request, err error) {
+	var werr *WriteError
+	if !errors.As(err, &werr) {
+		werr = &WriteError{Err: err}
+	}
+	if cfg.OnError != nil {
+		cfg.OnError(r, werr)
+		return
+	}
+	log.Printf("rsvp failed to write a response: %s", err)
+}
+
+// PanicError is a panic that was recovered by [Adapter]. See [Config.OnPanic].
+type PanicError struct {
+	// Value is the value that was passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine that panicked, as given by [runtime/debug.Stack].
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// PanicHook receives panics that are recovered by [Adapter]. See [Config.OnPanic].
+type PanicHook func(r *http.Request, err *PanicError)
+
+func (cfg Config) onPanic(r *http.Request, err *PanicError) {
+	if cfg.OnPanic != nil {
+		cfg.OnPanic(r, err)
+		return
+	}
+	log.Printf("rsvp recovered a panic while serving %s %s: %v\n%s", r.Method, r.URL.Path, err.Value, err.Stack)
+}
+
+// renderErrorKind decides whether err, returned by codec, was caused by a template, a stream, the request being cancelled or timing out, or by the codec itself.
+func renderErrorKind(codec Codec, err error) WriteErrorKind {
+	var streamErr *streamError
+	if errors.As(err, &streamErr) {
+		return WriteErrorStream
+	}
+	if errors.Is(err, context.Canceled) {
+		return WriteErrorClientDisconnect
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return WriteErrorTimeout
+	}
+	switch codec.(type) {
+	case htmlTemplateCodec, textTemplateCodec:
+		return WriteErrorTemplate
+	}
+	var execErr text.ExecError
+	if errors.As(err, &execErr) {
+		return WriteErrorTemplate
+	}
+	return WriteErrorEncoding
+}
@@ -0,0 +1,102 @@
+package rsvp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Teajey/rsvp/negotiate"
+)
+
+// Explanation describes how a response was negotiated. See [Explain].
+type Explanation struct {
+	// Accept is the parsed Accept header of the request, in order of precedence.
+	Accept negotiate.Header
+	// Ext is the extension of the request path. It is only detected on GET requests.
+	Ext string
+	// ExtMediaType is the media type registered for Ext, if any.
+	ExtMediaType string
+	// ExtUnsupported is set when Ext is not one of Supported, so the status is 404 Not Found.
+	ExtUnsupported bool
+	// Supported are the media types that the [Body] is able to be rendered as, in the order given by [Body.MediaTypes].
+	Supported []string
+	// Comparisons are every offer that was weighed against Accept, in the order they were made.
+	Comparisons []Comparison
+	// Fallback is set when none of Supported were acceptable, so the Accept header was ignored and the status is 406 Not Acceptable.
+	Fallback bool
+
+	// MediaType is the media type of the response.
+	MediaType string
+	// ContentType is the Content-Type header of the response. It is empty if the response is blank.
+	ContentType string
+	// Status is the status code of the response.
+	Status int
+}
+
+// Comparison is an offer that was weighed against the Accept header.
+type Comparison struct {
+	// MediaType is the offered media type, with the parameters of its Content-Type and variant.
+	MediaType string
+	// Proposal is the proposal of the Accept header that matched MediaType. It is the zero value if Matched is false.
+	Proposal negotiate.Proposal
+	// Matched reports whether any proposal of the Accept header matched MediaType.
+	Matched bool
+	// ServerQuality is the server-side quality of MediaType. See [Config.MediaTypeQuality].
+	ServerQuality float32
+	// Quality is the weight of Proposal multiplied by ServerQuality.
+	Quality float32
+	// Fallback is set if the comparison was made after nothing was acceptable, when the Accept header is treated as */*.
+	Fallback bool
+}
+
+// Explain negotiates the response that res would be written as for r, without writing anything.
+//
+// It is intended for debugging why a particular media type or status was chosen.
+func Explain(res Body, cfg Config, r *http.Request) Explanation {
+	var x Explanation
+	res.resolveTemplateName(r.Context(), "", cfg)
+	res.negotiateResponse(r, cfg, &x)
+	return x
+}
+
+func (x *Explanation) compare(accept negotiate.Header, offer negotiate.Offer) {
+	c := Comparison{
+		MediaType:     offer.Value,
+		ServerQuality: offer.Quality,
+		Fallback:      x.Fallback,
+	}
+	c.Proposal, c.Matched = accept.Match(offer.Value)
+	if c.Matched {
+		c.Quality = c.Proposal.Weight * offer.Quality
+	}
+	x.Comparisons = append(x.Comparisons, c)
+}
+
+// String condenses the explanation into a single line, as used by [Config.ExplainHeader], e.g.
+//
+//	application/json 200; accept="*/*"; ext=""; supported=text/plain,application/json,application/xml
+func (x Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %d", x.MediaType, x.Status)
+
+	accept := make([]string, len(x.Accept))
+	for i, p := range x.Accept {
+		accept[i] = p.Value
+		if p.Weight != 1 {
+			accept[i] += ";q=" + strconv.FormatFloat(float64(p.Weight), 'g', -1, 32)
+		}
+	}
+	fmt.Fprintf(&b, "; accept=%s", strconv.Quote(strings.Join(accept, ", ")))
+	fmt.Fprintf(&b, "; ext=%s", strconv.Quote(x.Ext))
+	fmt.Fprintf(&b, "; supported=%s", strings.Join(x.Supported, ","))
+
+	if x.ExtUnsupported {
+		b.WriteString("; ext-unsupported")
+	}
+	if x.Fallback {
+		b.WriteString("; not-acceptable")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package rsvp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+func TestExplain(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/xml;q=0.5, application/json")
+	cfg := rsvp.Config{MediaTypeQuality: map[string]float32{"text/plain": 0.5}}
+
+	x := rsvp.Explain(rsvp.Data("Hello"), cfg, req)
+
+	assert.Eq(t, "accept length", 2, len(x.Accept))
+	assert.Eq(t, "first proposal", "application/json", x.Accept[0].Value)
+	assert.Eq(t, "second proposal", "application/xml", x.Accept[1].Value)
+	assert.Eq(t, "ext", "", x.Ext)
+	assert.Eq(t, "supported", "text/plain application/json application/xml", strings.Join(x.Supported, " "))
+
+	assert.Eq(t, "comparisons", 3, len(x.Comparisons))
+	plain := x.Comparisons[0]
+	assert.Eq(t, "plain media type", "text/plain;charset=utf-8", plain.MediaType)
+	assert.Eq(t, "plain matched", false, plain.Matched)
+	assert.Eq(t, "plain server quality", 0.5, plain.ServerQuality)
+	assert.Eq(t, "plain quality", 0, plain.Quality)
+	xml := x.Comparisons[2]
+	assert.Eq(t, "xml matched", true, xml.Matched)
+	assert.Eq(t, "xml proposal", "application/xml", xml.Proposal.Value)
+	assert.Eq(t, "xml quality", 0.5, xml.Quality)
+
+	assert.Eq(t, "media type", "application/json", x.MediaType)
+	assert.Eq(t, "content type", "application/json", x.ContentType)
+	assert.Eq(t, "status", http.StatusOK, x.Status)
+	assert.Eq(t, "ext unsupported", false, x.ExtUnsupported)
+	assert.Eq(t, "fallback", false, x.Fallback)
+}
+
+func TestExplainUnsupportedExtension(t *testing.T) {
+	req := httptest.NewRequest("GET", "/report.csv", nil)
+
+	x := rsvp.Explain(rsvp.Data("Hello"), rsvp.Config{}, req)
+
+	assert.Eq(t, "ext", "csv", x.Ext)
+	assert.Eq(t, "ext media type", "text/csv", x.ExtMediaType)
+	assert.Eq(t, "ext unsupported", true, x.ExtUnsupported)
+	assert.Eq(t, "media type", "text/plain", x.MediaType)
+	assert.Eq(t, "status", http.StatusNotFound, x.Status)
+}
+
+func TestExplainNotAcceptable(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "image/png")
+
+	x := rsvp.Explain(rsvp.Data("Hello"), rsvp.Config{}, req)
+
+	assert.Eq(t, "fallback", true, x.Fallback)
+	assert.Eq(t, "comparisons", 6, len(x.Comparisons))
+	assert.Eq(t, "first comparison is not a fallback", false, x.Comparisons[0].Fallback)
+	assert.Eq(t, "fourth comparison is a fallback", true, x.Comparisons[3].Fallback)
+	assert.Eq(t, "fallback proposal", "*/*", x.Comparisons[3].Proposal.Value)
+	assert.Eq(t, "media type", "text/plain", x.MediaType)
+	assert.Eq(t, "status", http.StatusNotAcceptable, x.Status)
+}
+
+func TestExplainHeader(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/xml;q=0.5, application/json")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data("Hello"), rsvp.Config{ExplainHeader: "Rsvp-Explain"})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	expected := `application/json 200; accept="application/json, application/xml;q=0.5"; ext=""; supported=text/plain,application/json,application/xml`
+	assert.Eq(t, "explain header", expected, rec.Header().Get("Rsvp-Explain"))
+}
+
+func TestExplainHeaderOff(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data("Hello"), rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "explain headers", 0, len(rec.Header().Values("Rsvp-Explain")))
+}
@@ -0,0 +1,376 @@
+package rsvp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	SupportedMediaTypeAtom string = "application/atom+xml"
+	SupportedMediaTypeRss  string = "application/rss+xml"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// Feed is a web feed, which is offered as application/atom+xml and application/rss+xml. [Body.Data] may be a Feed, or implement [Feeder].
+type Feed struct {
+	// Title is the title of the feed.
+	Title string
+	// Description describes the feed. It is the Atom subtitle.
+	Description string
+	// Link is the URL of the web page that the feed belongs to.
+	Link string
+	// SelfLink is the URL of the feed itself. If it ends with .atom or .rss, the extension is swapped for that of the format being rendered, so that it links to the same format.
+	SelfLink string
+	// ID permanently identifies the feed in Atom. Defaults to SelfLink, or Link.
+	ID string
+	// Language of the feed, e.g. "en-nz".
+	Language string
+	// Author of the feed.
+	Author FeedAuthor
+	// Updated is when the feed last changed. Defaults to when its latest entry was updated. Atom requires it, so if no entry has a date either, the time of rendering is used.
+	Updated time.Time
+	Entries []FeedEntry
+}
+
+// FeedEntry is an entry of a [Feed]. It is an item in RSS.
+type FeedEntry struct {
+	// ID permanently identifies the entry, as the Atom id and the RSS guid. Defaults to Link.
+	ID    string
+	Title string
+	// Link is the URL of the entry's web page.
+	Link string
+	// Summary is a short description of the entry. It is used as the RSS description, unless it is empty.
+	Summary string
+	// Content is the full content of the entry as HTML. It is used as the RSS description if Summary is empty.
+	Content    string
+	Author     FeedAuthor
+	Categories []string
+	Published  time.Time
+	// Updated defaults to Published. Atom requires it, so if both are zero, the Updated of the Feed is used.
+	Updated time.Time
+}
+
+// FeedAuthor is the author of a [Feed] or [FeedEntry]. RSS only includes authors that have an Email.
+type FeedAuthor struct {
+	Name  string
+	Email string
+	URI   string
+}
+
+// Feeder may be implemented by [Body.Data] to offer it as application/atom+xml and application/rss+xml, alongside any other media type it is offered as. For example, a list of blog posts may be rendered by a template as text/html and also be offered as a feed.
+type Feeder interface {
+	Feed() Feed
+}
+
+func asFeed(data any) (Feed, bool) {
+	switch f := data.(type) {
+	case Feed:
+		return f, true
+	case *Feed:
+		if f != nil {
+			return *f, true
+		}
+	case Feeder:
+		return f.Feed(), true
+	}
+	return Feed{}, false
+}
+
+func (f Feed) id() string {
+	for _, id := range []string{f.ID, f.SelfLink, f.Link} {
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// selfLink returns SelfLink with the extension ext, if it has the extension of a feed.
+func (f Feed) selfLink(ext string) string {
+	u, err := url.Parse(f.SelfLink)
+	if err != nil {
+		return f.SelfLink
+	}
+	switch path.Ext(u.Path) {
+	case ".atom", ".rss":
+		u.Path = strings.TrimSuffix(u.Path, path.Ext(u.Path)) + "." + ext
+		return u.String()
+	}
+	return f.SelfLink
+}
+
+func (f Feed) updated() time.Time {
+	if !f.Updated.IsZero() {
+		return f.Updated
+	}
+	var latest time.Time
+	for _, e := range f.Entries {
+		if u := e.updated(); u.After(latest) {
+			latest = u
+		}
+	}
+	return latest
+}
+
+func (e FeedEntry) id() string {
+	if e.ID != "" {
+		return e.ID
+	}
+	return e.Link
+}
+
+func (e FeedEntry) updated() time.Time {
+	if !e.Updated.IsZero() {
+		return e.Updated
+	}
+	return e.Published
+}
+
+// feedDate formats t with layout, or returns "" if t is zero, so that an optional element with omitempty is left out.
+func feedDate(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+// encodeFeed writes the prolog of [XmlOptions] and doc, declaring the prefixed namespaces of XmlOptions on start.
+func encodeFeed(w io.Writer, res *Body, cfg Config, start xml.StartElement, doc any) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent(cfg.XmlPrefix, cfg.XmlIndent)
+	opts := res.xmlOptions(cfg)
+	opts.Namespace = ""
+	opts.declareNamespaces(&start)
+	err := opts.writeProlog(enc)
+	if err == nil {
+		err = enc.EncodeElement(doc, start)
+	}
+	if err == nil {
+		err = enc.Close()
+	}
+	return err
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+	URI   string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content"`
+}
+
+type atomFeed struct {
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   *atomPerson `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+func newAtomPerson(a FeedAuthor) *atomPerson {
+	if a.Name == "" {
+		return nil
+	}
+	return &atomPerson{Name: a.Name, Email: a.Email, URI: a.URI}
+}
+
+func newAtomFeed(f Feed) atomFeed {
+	updated := f.updated()
+	if updated.IsZero() {
+		updated = time.Now().UTC()
+	}
+	doc := atomFeed{
+		ID:       f.id(),
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  updated.Format(time.RFC3339),
+		Author:   newAtomPerson(f.Author),
+	}
+	if f.Link != "" {
+		doc.Links = append(doc.Links, atomLink{Rel: "alternate", Href: f.Link})
+	}
+	if f.SelfLink != "" {
+		doc.Links = append(doc.Links, atomLink{Rel: "self", Type: SupportedMediaTypeAtom, Href: f.selfLink("atom")})
+	}
+	for _, e := range f.Entries {
+		entryUpdated := e.updated()
+		if entryUpdated.IsZero() {
+			entryUpdated = updated
+		}
+		entry := atomEntry{
+			ID:        e.id(),
+			Title:     e.Title,
+			Published: feedDate(e.Published, time.RFC3339),
+			Updated:   entryUpdated.Format(time.RFC3339),
+			Author:    newAtomPerson(e.Author),
+			Summary:   e.Summary,
+		}
+		if e.Link != "" {
+			entry.Links = []atomLink{{Rel: "alternate", Href: e.Link}}
+		}
+		for _, c := range e.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		if e.Content != "" {
+			entry.Content = &atomText{Type: "html", Text: e.Content}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return doc
+}
+
+type atomCodec struct{}
+
+func (atomCodec) MediaType() string    { return SupportedMediaTypeAtom }
+func (atomCodec) ContentType() string  { return "application/atom+xml" }
+func (atomCodec) Extensions() []string { return []string{"atom"} }
+
+func (atomCodec) CanEncode(res *Body, cfg Config) bool {
+	_, ok := asFeed(res.Data)
+	return ok
+}
+
+func (atomCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	f, _ := asFeed(res.Data)
+	start := xml.StartElement{Name: xml.Name{Space: atomNamespace, Local: "feed"}}
+	err := encodeFeed(w, res, cfg, start, newAtomFeed(f))
+	if err != nil {
+		return fmt.Errorf("rendering feed as Atom: %w", err)
+	}
+	return nil
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title,omitempty"`
+	Link        string   `xml:"link,omitempty"`
+	Description string   `xml:"description,omitempty"`
+	Author      string   `xml:"author,omitempty"`
+	Categories  []string `xml:"category"`
+	Guid        *rssGuid `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+}
+
+type rssChannel struct {
+	Title          string    `xml:"title"`
+	Link           string    `xml:"link"`
+	Description    string    `xml:"description"`
+	Language       string    `xml:"language,omitempty"`
+	ManagingEditor string    `xml:"managingEditor,omitempty"`
+	LastBuildDate  string    `xml:"lastBuildDate,omitempty"`
+	SelfLink       *atomLink `xml:"atom:link"`
+	Items          []rssItem `xml:"item"`
+}
+
+type rssDoc struct {
+	Channel rssChannel `xml:"channel"`
+}
+
+// rssPerson formats a as an RSS author, which must have an email address.
+func rssPerson(a FeedAuthor) string {
+	if a.Email == "" {
+		return ""
+	}
+	if a.Name == "" {
+		return a.Email
+	}
+	return fmt.Sprintf("%s (%s)", a.Email, a.Name)
+}
+
+func newRssDoc(f Feed) rssDoc {
+	doc := rssDoc{
+		Channel: rssChannel{
+			Title:          f.Title,
+			Link:           f.Link,
+			Description:    f.Description,
+			Language:       f.Language,
+			ManagingEditor: rssPerson(f.Author),
+			LastBuildDate:  feedDate(f.updated(), time.RFC1123Z),
+		},
+	}
+	if f.SelfLink != "" {
+		doc.Channel.SelfLink = &atomLink{Rel: "self", Type: SupportedMediaTypeRss, Href: f.selfLink("rss")}
+	}
+	for _, e := range f.Entries {
+		item := rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Summary,
+			Author:      rssPerson(e.Author),
+			Categories:  e.Categories,
+			PubDate:     feedDate(e.Published, time.RFC1123Z),
+		}
+		if item.Description == "" {
+			item.Description = e.Content
+		}
+		if id := e.id(); id != "" {
+			item.Guid = &rssGuid{IsPermaLink: id == e.Link, Value: id}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return doc
+}
+
+type rssCodec struct{}
+
+func (rssCodec) MediaType() string    { return SupportedMediaTypeRss }
+func (rssCodec) ContentType() string  { return "application/rss+xml" }
+func (rssCodec) Extensions() []string { return []string{"rss"} }
+
+func (rssCodec) CanEncode(res *Body, cfg Config) bool {
+	_, ok := asFeed(res.Data)
+	return ok
+}
+
+func (rssCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	f, _ := asFeed(res.Data)
+	start := xml.StartElement{
+		Name: xml.Name{Local: "rss"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "version"}, Value: "2.0"},
+			{Name: xml.Name{Local: "xmlns:atom"}, Value: atomNamespace},
+		},
+	}
+	err := encodeFeed(w, res, cfg, start, newRssDoc(f))
+	if err != nil {
+		return fmt.Errorf("rendering feed as RSS: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package rsvp_test
+
+import (
+	"encoding/xml"
+	html "html/template"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+type blogPost struct {
+	Slug      string
+	Title     string
+	Body      string
+	Published time.Time
+}
+
+type blogPosts []blogPost
+
+func (ps blogPosts) Feed() rsvp.Feed {
+	f := rsvp.Feed{
+		Title:       "My stuff",
+		Description: "It's cool",
+		Link:        "https://example.com/posts",
+		SelfLink:    "https://example.com/posts.atom",
+		Language:    "en",
+		Author:      rsvp.FeedAuthor{Name: "Alice", Email: "alice@example.com"},
+	}
+	for _, p := range ps {
+		f.Entries = append(f.Entries, rsvp.FeedEntry{
+			Title:      p.Title,
+			Link:       "https://example.com/posts/" + p.Slug,
+			Content:    p.Body,
+			Categories: []string{"pets"},
+			Published:  p.Published,
+		})
+	}
+	return f
+}
+
+var posts = blogPosts{
+	{Slug: "new-post", Title: "New post", Body: "<p>I got a pet</p>", Published: time.Date(2024, 4, 1, 9, 30, 0, 0, time.FixedZone("NZDT", 13*60*60))},
+	{Slug: "old-post", Title: "Old post", Body: "<p>Hello & welcome</p>", Published: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+}
+
+func renderFeed(t *testing.T, path string, data any) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data(data), rsvp.Config{XmlIndent: "  "})(rec, req)
+	assert.FatalErr(t, "handler", err)
+	assert.Eq(t, "status", 200, rec.Code)
+
+	return rec
+}
+
+func TestFeedAtom(t *testing.T) {
+	rec := renderFeed(t, "/posts.atom", posts)
+
+	assert.Eq(t, "content type", "application/atom+xml", rec.Header().Get("Content-Type"))
+	assert.SnapshotText(t, rec.Body.String()+"\n")
+}
+
+func TestFeedRss(t *testing.T) {
+	rec := renderFeed(t, "/posts.rss", posts)
+
+	assert.Eq(t, "content type", "application/rss+xml", rec.Header().Get("Content-Type"))
+	assert.SnapshotText(t, rec.Body.String()+"\n")
+}
+
+func TestFeedGuidIsNotPermaLink(t *testing.T) {
+	f := rsvp.Feed{Entries: []rsvp.FeedEntry{{ID: "urn:uuid:1", Link: "https://example.com/1"}}}
+
+	rec := renderFeed(t, "/posts.rss", &f)
+
+	expected := `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title></title>
+    <link></link>
+    <description></description>
+    <item>
+      <link>https://example.com/1</link>
+      <guid isPermaLink="false">urn:uuid:1</guid>
+    </item>
+  </channel>
+</rss>`
+	assert.Eq(t, "body", expected, rec.Body.String())
+}
+
+func TestFeedRssDoesNotRedeclareAtomNamespace(t *testing.T) {
+	req := httptest.NewRequest("GET", "/posts.rss", nil)
+	rec := httptest.NewRecorder()
+	cfg := rsvp.Config{XmlOptions: rsvp.XmlOptions{Namespaces: map[string]string{
+		"atom":  "http://www.w3.org/2005/Atom",
+		"media": "http://search.yahoo.com/mrss/",
+	}}}
+
+	err := makeHandler(rsvp.Data(rsvp.Feed{Title: "My stuff"}), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	expected := `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/"><channel><title>My stuff</title><link></link><description></description></channel></rss>`
+	assert.Eq(t, "body", expected, rec.Body.String())
+}
+
+func TestFeedAtomUpdatedIsNeverEmpty(t *testing.T) {
+	f := rsvp.Feed{Title: "My stuff", Entries: []rsvp.FeedEntry{{ID: "urn:uuid:1", Title: "Undated"}}}
+	before := time.Now().Truncate(time.Second)
+
+	rec := renderFeed(t, "/posts.atom", f)
+
+	var doc struct {
+		Updated string `xml:"updated"`
+		Entries []struct {
+			Updated string `xml:"updated"`
+		} `xml:"entry"`
+	}
+	err := xml.Unmarshal(rec.Body.Bytes(), &doc)
+	assert.FatalErr(t, "unmarshal", err)
+	updated, err := time.Parse(time.RFC3339, doc.Updated)
+	assert.FatalErr(t, "feed updated", err)
+	assert.True(t, "feed updated is the time of rendering", !updated.Before(before))
+	assert.Eq(t, "entry updated", doc.Updated, doc.Entries[0].Updated)
+}
+
+func TestFeedAtomEntryUpdatedDefaultsToFeed(t *testing.T) {
+	updated := time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)
+	f := rsvp.Feed{Title: "My stuff", Updated: updated, Entries: []rsvp.FeedEntry{{ID: "urn:uuid:1", Title: "Undated"}}}
+
+	rec := renderFeed(t, "/posts.atom", f)
+
+	assert.True(t, "entry updated", strings.Contains(rec.Body.String(), "<title>Undated</title>\n    <updated>2024-04-02T00:00:00Z</updated>"))
+}
+
+func TestFeedMediaTypes(t *testing.T) {
+	res := rsvp.Body{Data: posts, TemplateName: "posts.html"}
+	cfg := rsvp.Config{HtmlTemplate: html.Must(html.New("posts.html").Parse(`{{range .}}<h1>{{.Title}}</h1>{{end}}`))}
+
+	actual := slices.Collect(res.MediaTypes(cfg))
+
+	expected := []string{
+		rsvp.SupportedMediaTypeJson,
+		rsvp.SupportedMediaTypeXml,
+		rsvp.SupportedMediaTypeNdjson,
+		rsvp.SupportedMediaTypeJsonl,
+		rsvp.SupportedMediaTypeJsonSeq,
+		rsvp.SupportedMediaTypeCsv,
+		rsvp.SupportedMediaTypeTsv,
+		rsvp.SupportedMediaTypeAtom,
+		rsvp.SupportedMediaTypeRss,
+		rsvp.SupportedMediaTypeHtml,
+	}
+	assert.SlicesEq(t, "media types", expected, actual)
+}
+
+func TestFeedNegotiation(t *testing.T) {
+	cases := map[string]string{
+		"application/atom+xml":              "application/atom+xml",
+		"application/rss+xml":               "application/rss+xml",
+		"text/html":                         "text/html; charset=utf-8",
+		"application/json;q=0.9, */*;q=0.1": "application/json",
+	}
+
+	for accept, contentType := range cases {
+		t.Run(accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/posts", nil)
+			req.Header.Set("Accept", accept)
+			rec := httptest.NewRecorder()
+			cfg := rsvp.Config{HtmlTemplate: html.Must(html.New("posts.html").Parse(`{{range .}}<h1>{{.Title}}</h1>{{end}}`))}
+
+			rsvp.NewAdapter(cfg).AdaptFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+				w.DefaultTemplateName("posts.html")
+				return rsvp.Data(posts)
+			}).ServeHTTP(rec, req)
+
+			assert.Eq(t, "status", 200, rec.Code)
+			assert.Eq(t, "content type", contentType, rec.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package rsvp
+
+import (
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValidationError is returned by [Decode] when the request body could not be bound to a struct, or when a [Validator] reports problems.
+//
+// It may be returned directly from a handler with [ValidationError.Body]. Browsers will see the handler's HTML template re-executed with this value as its data, so that a form can be re-rendered with the submitted values and field errors:
+//
+//	<input name="email" value="{{.Value "email"}}">
+//	{{with .FieldError "email"}}<p class="error">{{.}}</p>{{end}}
+type ValidationError struct {
+	// Values are the submitted form values. They are nil if the request body was not a form.
+	Values url.Values `json:"-"`
+	// Fields maps field names to their problems.
+	Fields map[string][]string `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(e.Fields)) {
+		problems = append(problems, fmt.Sprintf("%s %s", name, strings.Join(e.Fields[name], ", ")))
+	}
+	return fmt.Sprintf("invalid request body: %s", strings.Join(problems, "; "))
+}
+
+// Add records a problem with the named field.
+func (e *ValidationError) Add(field, message string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string][]string)
+	}
+	e.Fields[field] = append(e.Fields[field], message)
+}
+
+// Has reports whether there are any problems with the named field.
+func (e *ValidationError) Has(field string) bool {
+	return len(e.Fields[field]) > 0
+}
+
+// FieldError returns the problems with the named field joined by ", ", or an empty string if there are none.
+func (e *ValidationError) FieldError(field string) string {
+	return strings.Join(e.Fields[field], ", ")
+}
+
+// Value returns the first submitted value of the named field.
+func (e *ValidationError) Value(field string) string {
+	return e.Values.Get(field)
+}
+
+// Body returns a 422 Unprocessable Entity response with this error as its data.
+func (e *ValidationError) Body() Body {
+	return Data(e).StatusUnprocessableEntity()
+}
+
+func (e *ValidationError) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "errors"}
+	err := enc.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(e.Fields)) {
+		for _, message := range e.Fields[name] {
+			field := xml.StartElement{Name: xml.Name{Local: "field"}, Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: name}}}
+			err = enc.EncodeElement(message, field)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// Validator may be implemented by values passed to [Decode]. Validate is called after the request body has been decoded, and any problems added to errs are returned by Decode.
+type Validator interface {
+	Validate(errs *ValidationError)
+}
+
+// bindForm sets the fields of the struct pointed to by v from values and files, as named by their `form` tags.
+//
+// A tag of "-" skips the field. A tag option of "required" reports fields that are missing or empty, e.g. `form:"email,required"`.
+// Untagged fields are bound by their Go name. Embedded structs, and pointers to structs, have their fields bound as if they belonged to the outer struct. Embedded pointers are allocated if they are nil.
+//
+// Supported field types are strings, bools, numbers, [encoding.TextUnmarshaler], *[multipart.FileHeader], and slices of these.
+func bindForm(values url.Values, files map[string][]*multipart.FileHeader, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode a form request body into %T", v)
+	}
+
+	verr := &ValidationError{Values: values}
+	bindStruct(rv.Elem(), values, files, verr)
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+func bindStruct(rv reflect.Value, values url.Values, files map[string][]*multipart.FileHeader, verr *ValidationError) {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("form")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fv := rv.Field(i)
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			bindStruct(fv, values, files, verr)
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct && !field.Type.Implements(textUnmarshalerType) {
+			if fv.IsNil() {
+				fv.Set(reflect.New(field.Type.Elem()))
+			}
+			bindStruct(fv.Elem(), values, files, verr)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		required := slices.Contains(strings.Split(opts, ","), "required")
+
+		if bindFiles(fv, files[name]) {
+			if required && len(files[name]) == 0 {
+				verr.Add(name, "is required")
+			}
+			continue
+		}
+
+		submitted := slices.DeleteFunc(slices.Clone(values[name]), func(s string) bool { return s == "" })
+		if len(submitted) == 0 {
+			if required {
+				verr.Add(name, "is required")
+			}
+			continue
+		}
+
+		err := bindValues(fv, submitted)
+		if err != nil {
+			verr.Add(name, err.Error())
+		}
+	}
+}
+
+var fileHeaderType = reflect.TypeFor[*multipart.FileHeader]()
+
+func bindFiles(fv reflect.Value, headers []*multipart.FileHeader) bool {
+	switch {
+	case fv.Type() == fileHeaderType:
+		if len(headers) > 0 {
+			fv.Set(reflect.ValueOf(headers[0]))
+		}
+		return true
+	case fv.Kind() == reflect.Slice && fv.Type().Elem() == fileHeaderType:
+		fv.Set(reflect.ValueOf(headers))
+		return true
+	}
+	return false
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+func bindValues(fv reflect.Value, submitted []string) error {
+	if fv.Kind() == reflect.Slice && !reflect.PointerTo(fv.Type()).Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(fv.Type(), len(submitted), len(submitted))
+		for i, s := range submitted {
+			err := bindValue(slice.Index(i), s)
+			if err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+
+	return bindValue(fv, submitted[0])
+}
+
+func bindValue(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Pointer {
+		ptr := reflect.New(fv.Type().Elem())
+		err := bindValue(ptr.Elem(), s)
+		if err != nil {
+			return err
+		}
+		fv.Set(ptr)
+		return nil
+	}
+
+	if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		err := u.UnmarshalText([]byte(s))
+		if err != nil {
+			return fmt.Errorf("is invalid")
+		}
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		switch strings.ToLower(s) {
+		case "on", "true", "1", "yes":
+			fv.SetBool(true)
+		case "off", "false", "0", "no":
+			fv.SetBool(false)
+		default:
+			return fmt.Errorf("must be true or false")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a whole number")
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a positive whole number")
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		fv.SetFloat(n)
+	default:
+		return fmt.Errorf("cannot be bound to %s", fv.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package rsvp_test
+
+import (
+	"errors"
+	html "html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+type signupForm struct {
+	Email      string   `form:"email,required" json:"email"`
+	Age        int      `form:"age" json:"age"`
+	Newsletter bool     `form:"newsletter" json:"newsletter"`
+	Interests  []string `form:"interest" json:"interests"`
+	Ignored    string   `form:"-" json:"-"`
+}
+
+func (f *signupForm) Validate(errs *rsvp.ValidationError) {
+	if f.Email != "" && !strings.Contains(f.Email, "@") {
+		errs.Add("email", "must be an email address")
+	}
+}
+
+func postForm(target, body string) *http.Request {
+	req := httptest.NewRequest("POST", target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestDecodeFormIntoStruct(t *testing.T) {
+	req := postForm("/signup", "email=tea%40example.com&age=30&newsletter=on&interest=go&interest=http&Ignored=x")
+
+	var form signupForm
+	err := rsvp.Decode(req, rsvp.Config{}, &form)
+	assert.FatalErr(t, "decode", err)
+	assert.Eq(t, "email", "tea@example.com", form.Email)
+	assert.Eq(t, "age", 30, form.Age)
+	assert.Eq(t, "newsletter", true, form.Newsletter)
+	assert.SlicesEq(t, "interests", []string{"go", "http"}, form.Interests)
+	assert.Eq(t, "ignored", "", form.Ignored)
+}
+
+type FormAddress struct {
+	City string `form:"city,required"`
+}
+
+type FormContact struct {
+	Phone string `form:"phone"`
+}
+
+type deliveryForm struct {
+	Name string `form:"name"`
+	FormAddress
+	*FormContact
+}
+
+func TestDecodeFormIntoEmbeddedStructs(t *testing.T) {
+	req := postForm("/delivery", "name=Tea&city=Wellington&phone=021")
+
+	var form deliveryForm
+	err := rsvp.Decode(req, rsvp.Config{}, &form)
+	assert.FatalErr(t, "decode", err)
+	assert.Eq(t, "name", "Tea", form.Name)
+	assert.Eq(t, "city", "Wellington", form.City)
+	assert.True(t, "embedded pointer is allocated", form.FormContact != nil)
+	assert.Eq(t, "phone", "021", form.Phone)
+}
+
+func TestDecodeFormValidationErrors(t *testing.T) {
+	req := postForm("/signup", "age=thirty&newsletter=maybe")
+
+	var form signupForm
+	err := rsvp.Decode(req, rsvp.Config{}, &form)
+	var verr *rsvp.ValidationError
+	assert.FatalErrAs(t, "decode", err, &verr)
+	assert.Eq(t, "email", "is required", verr.FieldError("email"))
+	assert.Eq(t, "age", "must be a whole number", verr.FieldError("age"))
+	assert.Eq(t, "newsletter", "must be true or false", verr.FieldError("newsletter"))
+	assert.Eq(t, "submitted age", "thirty", verr.Value("age"))
+}
+
+func TestDecodeJsonIsValidated(t *testing.T) {
+	req := httptest.NewRequest("POST", "/signup", strings.NewReader(`{"email":"nope"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	var form signupForm
+	err := rsvp.Decode(req, rsvp.Config{}, &form)
+	var verr *rsvp.ValidationError
+	assert.FatalErrAs(t, "decode", err, &verr)
+	assert.Eq(t, "email", "must be an email address", verr.FieldError("email"))
+}
+
+func signupHandler(cfg rsvp.Config) http.HandlerFunc {
+	return rsvp.NewAdapter(cfg).AdaptFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		w.DefaultTemplateName("signup")
+
+		var form signupForm
+		err := rsvp.Decode(r, cfg, &form)
+		var verr *rsvp.ValidationError
+		if errors.As(err, &verr) {
+			return verr.Body()
+		}
+		if err != nil {
+			return rsvp.Data(err.Error()).StatusBadRequest()
+		}
+
+		return rsvp.Data(form).StatusSeeOther("/welcome")
+	})
+}
+
+func TestFormValidationErrorRerendersHtml(t *testing.T) {
+	cfg := rsvp.Config{
+		HtmlTemplate: html.Must(html.New("signup").Parse(`<input name="email" value="{{.Value "email"}}">{{with .FieldError "email"}}<p>{{.}}</p>{{end}}`)),
+	}
+
+	req := postForm("/signup", "email=nope")
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	signupHandler(cfg).ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Eq(t, "Content type", "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "body contents", `<input name="email" value="nope"><p>must be an email address</p>`, rec.Body.String())
+}
+
+func TestFormValidationErrorRendersJson(t *testing.T) {
+	cfg := rsvp.Config{}
+
+	req := postForm("/signup", "age=x")
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	signupHandler(cfg).ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/json", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "body contents", `{"fields":{"age":["must be a whole number"],"email":["is required"]}}`+"\n", rec.Body.String())
+}
+
+func TestFormValidationErrorRendersXml(t *testing.T) {
+	cfg := rsvp.Config{}
+
+	req := postForm("/signup", "age=x")
+	req.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+	signupHandler(cfg).ServeHTTP(rec, req)
+
+	assert.Eq(t, "Status code", http.StatusUnprocessableEntity, rec.Code)
+	assert.Eq(t, "body contents", `<errors><field name="age">must be a whole number</field><field name="email">is required</field></errors>`, rec.Body.String())
+}
+
+func TestFormSuccessRedirects(t *testing.T) {
+	req := postForm("/signup", "email=tea%40example.com")
+	rec := httptest.NewRecorder()
+	signupHandler(rsvp.Config{}).ServeHTTP(rec, req)
+
+	assert.Eq(t, "Status code", http.StatusSeeOther, rec.Code)
+	assert.Eq(t, "Location", "/welcome", rec.Result().Header.Get("Location"))
+}
@@ -15,3 +15,13 @@ type HandlerFunc func(w ResponseWriter, r *http.Request) Body
 func (f HandlerFunc) ServeHTTP(w ResponseWriter, r *http.Request) Body {
 	return f(w, r)
 }
+
+// HandlerFuncE is like [HandlerFunc], but may return an error instead of a [Body].
+//
+// Errors are turned into a [Body] by [Config.ErrorMapper]. See [Adapter.AdaptFuncE].
+type HandlerFuncE func(w ResponseWriter, r *http.Request) (Body, error)
+
+// StatusCoder may be implemented by errors returned from [HandlerFuncE] to choose the status code of the response.
+type StatusCoder interface {
+	StatusCode() int
+}
@@ -1,6 +1,8 @@
 package rsvp_test
 
 import (
+	"errors"
+	"fmt"
 	html "html/template"
 	"net/http"
 	"net/http/httptest"
@@ -53,3 +55,85 @@ func TestResponseHeader(t *testing.T) {
 	assert.Eq(t, "Content type", "world", resp.Header.Get("hello"))
 	assert.Eq(t, "body contents", "", rec.Body.String())
 }
+
+type teapotError struct{}
+
+func (teapotError) Error() string   { return "the kettle is busy" }
+func (teapotError) StatusCode() int { return http.StatusTeapot }
+
+type dbError struct{}
+
+func (dbError) Error() string   { return "connection refused to 10.0.0.1:5432" }
+func (dbError) StatusCode() int { return http.StatusServiceUnavailable }
+
+func serveE(t *testing.T, cfg rsvp.Config, handler rsvp.HandlerFuncE) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	rsvp.NewAdapter(cfg).AdaptFuncE(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerFuncEBody(t *testing.T) {
+	rec := serveE(t, rsvp.Config{}, func(w rsvp.ResponseWriter, r *http.Request) (rsvp.Body, error) {
+		return rsvp.Data("ok"), nil
+	})
+	assert.Eq(t, "Status code", http.StatusOK, rec.Code)
+	assert.Eq(t, "body contents", `"ok"`+"\n", rec.Body.String())
+}
+
+func TestHandlerFuncEUnmappedErrorDoesNotLeak(t *testing.T) {
+	rec := serveE(t, rsvp.Config{}, func(w rsvp.ResponseWriter, r *http.Request) (rsvp.Body, error) {
+		return rsvp.Body{}, fmt.Errorf("querying users: %w", errors.New("password=hunter2"))
+	})
+	assert.Eq(t, "Status code", http.StatusInternalServerError, rec.Code)
+	assert.Eq(t, "body contents", `{"status":500,"title":"Internal Server Error"}`+"\n", rec.Body.String())
+}
+
+func TestHandlerFuncEProblemError(t *testing.T) {
+	rec := serveE(t, rsvp.Config{}, func(w rsvp.ResponseWriter, r *http.Request) (rsvp.Body, error) {
+		return rsvp.Body{}, fmt.Errorf("loading user: %w", rsvp.NewProblem(http.StatusNotFound, "no such user"))
+	})
+	assert.Eq(t, "Status code", http.StatusNotFound, rec.Code)
+	assert.Eq(t, "body contents", `{"detail":"no such user","status":404,"title":"Not Found"}`+"\n", rec.Body.String())
+}
+
+func TestHandlerFuncEProblemErrorWithoutStatus(t *testing.T) {
+	rec := serveE(t, rsvp.Config{}, func(w rsvp.ResponseWriter, r *http.Request) (rsvp.Body, error) {
+		return rsvp.Body{}, &rsvp.Problem{Title: "Something went wrong"}
+	})
+	assert.Eq(t, "Status code", http.StatusInternalServerError, rec.Code)
+	assert.Eq(t, "body contents", `{"status":500,"title":"Something went wrong"}`+"\n", rec.Body.String())
+}
+
+func TestHandlerFuncEStatusCoder(t *testing.T) {
+	rec := serveE(t, rsvp.Config{}, func(w rsvp.ResponseWriter, r *http.Request) (rsvp.Body, error) {
+		return rsvp.Body{}, fmt.Errorf("brewing: %w", teapotError{})
+	})
+	assert.Eq(t, "Status code", http.StatusTeapot, rec.Code)
+	assert.Eq(t, "body contents", `{"detail":"brewing: the kettle is busy","status":418,"title":"I'm a teapot"}`+"\n", rec.Body.String())
+}
+
+func TestHandlerFuncEServerStatusCoderDoesNotLeak(t *testing.T) {
+	rec := serveE(t, rsvp.Config{}, func(w rsvp.ResponseWriter, r *http.Request) (rsvp.Body, error) {
+		return rsvp.Body{}, dbError{}
+	})
+	assert.Eq(t, "Status code", http.StatusServiceUnavailable, rec.Code)
+	assert.Eq(t, "body contents", `{"status":503,"title":"Service Unavailable"}`+"\n", rec.Body.String())
+}
+
+func TestHandlerFuncECustomErrorMapper(t *testing.T) {
+	cfg := rsvp.Config{
+		ErrorMapper: func(w rsvp.ResponseWriter, r *http.Request, err error) rsvp.Body {
+			w.Header().Set("X-Error", "yes")
+			return rsvp.Data(err.Error()).StatusBadRequest()
+		},
+	}
+	rec := serveE(t, cfg, func(w rsvp.ResponseWriter, r *http.Request) (rsvp.Body, error) {
+		return rsvp.Body{}, errors.New("bad")
+	})
+	assert.Eq(t, "Status code", http.StatusBadRequest, rec.Code)
+	assert.Eq(t, "X-Error", "yes", rec.Result().Header.Get("X-Error"))
+	assert.Eq(t, "body contents", `"bad"`+"\n", rec.Body.String())
+}
@@ -1,8 +1,24 @@
 package rsvp
 
-import "strings"
+import (
+	"net/http"
+	"strings"
+)
 
 func contentTypeExtractMediaType(contentType string) string {
 	strs := strings.Split(contentType, ";")
 	return strings.TrimSpace(strs[0])
 }
+
+// addVary adds field to the Vary header of h, unless it is already listed in any of its comma-separated values.
+func addVary(h http.Header, field string) {
+	for _, value := range h.Values("Vary") {
+		for token := range strings.SplitSeq(value, ",") {
+			token = strings.TrimSpace(token)
+			if token == "*" || strings.EqualFold(token, field) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", field)
+}
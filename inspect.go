@@ -0,0 +1,13 @@
+package rsvp
+
+import "github.com/Teajey/rsvp/internal/inspect"
+
+func init() {
+	inspect.Status = func(body any) int {
+		res := body.(Body)
+		return res.status()
+	}
+	inspect.Location = func(body any) string {
+		return body.(Body).redirectLocation
+	}
+}
@@ -1,7 +0,0 @@
-//go:build !rsvp_logs
-
-package dev
-
-func Log(format string, v ...any) {
-	// NO-OP
-}
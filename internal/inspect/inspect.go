@@ -0,0 +1,11 @@
+// Package inspect exposes unexported details of rsvp.Body to rsvptest, without making them part of rsvp's API.
+//
+// The functions are set by package rsvp when it is initialised.
+package inspect
+
+var (
+	// Status returns the status code that an rsvp.Body will be written with, before negotiation.
+	Status func(body any) int
+	// Location returns the redirect location of an rsvp.Body.
+	Location func(body any) string
+)
@@ -0,0 +1,104 @@
+package rsvp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+type requestIDKey struct{}
+
+// requestIDHandler adds the request ID from the context to each record, like a typical tracing handler.
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestLoggerTracesNegotiation(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	cfg := rsvp.Config{Logger: slog.New(requestIDHandler{handler})}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/xml;q=0.5, application/json")
+	req = req.WithContext(context.WithValue(req.Context(), requestIDKey{}, "abc123"))
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data("Hello"), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	var chose map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var record map[string]any
+		err := dec.Decode(&record)
+		assert.FatalErr(t, "decoding log record", err)
+		assert.Eq(t, "level", "DEBUG", record["level"])
+		assert.Eq(t, "request_id", "abc123", record["request_id"])
+		if record["msg"] == "chose media type" {
+			chose = record
+		}
+	}
+
+	if chose == nil {
+		t.Fatal("media type choice was not logged")
+	}
+	assert.Eq(t, "accept", "application/xml;q=0.5, application/json", chose["accept"])
+	assert.Eq(t, "ext", "", chose["ext"])
+	assert.Eq(t, "supported", `[text/plain application/json application/xml]`, fmt.Sprint(chose["supported"]))
+	assert.Eq(t, "chosen", "application/json", chose["chosen"])
+}
+
+func TestLoggerDisabledAboveDebug(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := rsvp.Config{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data("Hello"), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "log output", "", buf.String())
+}
+
+func TestLoggerTracesPredeterminedMediaType(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := rsvp.Config{Logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/xml")
+
+	err := makeHandler(rsvp.Data("Hello"), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	var overridden map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var record map[string]any
+		err := dec.Decode(&record)
+		assert.FatalErr(t, "decoding log record", err)
+		if record["msg"] == "overriding supported media types with the predetermined media type" {
+			overridden = record
+		}
+	}
+
+	if overridden == nil {
+		t.Fatal("predetermined media type was not logged")
+	}
+	assert.Eq(t, "media_type", "application/xml", overridden["media_type"])
+}
@@ -1,5 +1,3 @@
-//go:build rsvp_msgpack
-
 package rsvp_test
 
 import (
@@ -30,7 +28,6 @@ func TestNilResponse(t *testing.T) {
 	expected := []string{
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -44,7 +41,6 @@ func TestBlankResponse(t *testing.T) {
 	expected := []string{
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -59,7 +55,6 @@ func TestEmptyStringResponse(t *testing.T) {
 		rsvp.SupportedMediaTypePlaintext,
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -73,7 +68,6 @@ func TestStructResponse(t *testing.T) {
 	expected := []string{
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -87,7 +81,9 @@ func TestSliceResponse(t *testing.T) {
 	expected := []string{
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
-		rsvp.SupportedMediaTypeMsgpack,
+		rsvp.SupportedMediaTypeNdjson,
+		rsvp.SupportedMediaTypeJsonl,
+		rsvp.SupportedMediaTypeJsonSeq,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -101,7 +97,6 @@ func TestMapResponse(t *testing.T) {
 	expected := []string{
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -116,7 +111,6 @@ func TestStringResponse(t *testing.T) {
 		rsvp.SupportedMediaTypePlaintext,
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -131,7 +125,6 @@ func TestBytesResponse(t *testing.T) {
 		rsvp.SupportedMediaTypeBytes,
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -152,7 +145,7 @@ func TestCsvResponse(t *testing.T) {
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
 		rsvp.SupportedMediaTypeCsv,
-		rsvp.SupportedMediaTypeMsgpack,
+		rsvp.SupportedMediaTypeTsv,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -169,7 +162,6 @@ func TestStructResponseWithHtmlTemplate(t *testing.T) {
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
 		rsvp.SupportedMediaTypeHtml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -185,7 +177,6 @@ func TestStructWithoutTemplateNameResponseWithHtmlTemplate(t *testing.T) {
 	expected := []string{
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -202,8 +193,8 @@ func TestCsvResponseWithHtmlTemplate(t *testing.T) {
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
 		rsvp.SupportedMediaTypeCsv,
+		rsvp.SupportedMediaTypeTsv,
 		rsvp.SupportedMediaTypeHtml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -220,8 +211,8 @@ func TestCsvResponseWithTextTemplate(t *testing.T) {
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
 		rsvp.SupportedMediaTypeCsv,
+		rsvp.SupportedMediaTypeTsv,
 		rsvp.SupportedMediaTypePlaintext,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -238,7 +229,6 @@ func TestStructResponseWithTextTemplate(t *testing.T) {
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
 		rsvp.SupportedMediaTypePlaintext,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -254,7 +244,6 @@ func TestStructWithoutTemplateNameResponseWithTextTemplate(t *testing.T) {
 	expected := []string{
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -273,7 +262,6 @@ func TestStructResponseWithTextAndHtmlTemplates(t *testing.T) {
 		rsvp.SupportedMediaTypeXml,
 		rsvp.SupportedMediaTypeHtml,
 		rsvp.SupportedMediaTypePlaintext,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -293,7 +281,6 @@ func TestStringResponseWithTextAndHtmlTemplates(t *testing.T) {
 		rsvp.SupportedMediaTypeXml,
 		rsvp.SupportedMediaTypeHtml,
 		rsvp.SupportedMediaTypePlaintext,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -311,9 +298,9 @@ func TestCsvResponseWithTextAndHtmlTemplates(t *testing.T) {
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
 		rsvp.SupportedMediaTypeCsv,
+		rsvp.SupportedMediaTypeTsv,
 		rsvp.SupportedMediaTypeHtml,
 		rsvp.SupportedMediaTypePlaintext,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -331,7 +318,6 @@ func TestBytesResponseWithHtmlTemplate(t *testing.T) {
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
 		rsvp.SupportedMediaTypeHtml,
-		rsvp.SupportedMediaTypeMsgpack,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
@@ -349,7 +335,18 @@ func TestStringResponseWithHtmlTemplate(t *testing.T) {
 		rsvp.SupportedMediaTypeJson,
 		rsvp.SupportedMediaTypeXml,
 		rsvp.SupportedMediaTypeHtml,
-		rsvp.SupportedMediaTypeMsgpack,
+	}
+
+	assert.SlicesEq(t, "media types", expected, actual)
+}
+
+func TestStructResponseWithoutXml(t *testing.T) {
+	cfg := rsvp.NewAdapter(rsvp.Config{}, rsvp.WithoutMediaTypes(rsvp.SupportedMediaTypeXml)).Config()
+	resp := rsvp.Body{Data: struct{}{}}
+	actual := slices.Collect(resp.MediaTypes(cfg))
+
+	expected := []string{
+		rsvp.SupportedMediaTypeJson,
 	}
 
 	assert.SlicesEq(t, "media types", expected, actual)
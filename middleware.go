@@ -1,14 +1,20 @@
 package rsvp
 
 import (
-	"log"
 	"net/http"
+	"runtime/debug"
 )
 
 // NewAdapter returns an rsvp middleware that adapts standard http.Handler to rsvp.Handler, using the provided config.
 //
+// opts are applied to a copy of cfg, so the same cfg may be used to build many Adapters.
+//
 // This is the primary entrypoint to using rsvp.
-func NewAdapter(cfg Config) Adapter {
+func NewAdapter(cfg Config, opts ...Option) Adapter {
+	cfg = cfg.clone()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	return Adapter{cfg}
 }
 
@@ -16,16 +22,63 @@ type Adapter struct {
 	config Config
 }
 
+// Config returns the [Config] that this Adapter writes responses with, after any [Option] has been applied.
+func (a Adapter) Config() Config {
+	return a.config.clone()
+}
+
+// AdaptFunc adapts next to an [http.HandlerFunc]. Errors that occur while writing the response are passed to [Config.OnError].
+//
+// If next panics, or rendering its [Body] panics, the panic is passed to [Config.OnPanic] and a negotiated 500 [Problem] is written.
+// If the status code has already been written, the handler panics with [http.ErrAbortHandler] instead, so that the connection is aborted and the client can tell that the response is incomplete.
 func (a Adapter) AdaptFunc(next func(w ResponseWriter, r *http.Request) Body) http.HandlerFunc {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		err := Write(rw, r, a.config, HandlerFunc(next))
+		w := responseWriter{
+			writer: rw,
+		}
+		defer a.recoverPanic(&w, r)
+
+		err := w.serve(r, a.config, HandlerFunc(next))
 		if err != nil {
-			log.Printf("rsvp failed to write a response: %s", err)
+			a.config.onError(r, err)
 			return
 		}
 	})
 }
 
+func (a Adapter) recoverPanic(w *responseWriter, r *http.Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	a.config.onPanic(r, &PanicError{Value: v, Stack: debug.Stack()})
+
+	if w.wroteHeader {
+		// A truncated response must not look complete to the client
+		panic(http.ErrAbortHandler)
+	}
+
+	err := w.writeFailure(r, a.config)
+	if err != nil {
+		a.config.onError(r, err)
+	}
+}
+
+// AdaptFuncE is like [Adapter.AdaptFunc], but next may return an error, which is turned into a [Body] by [Config.ErrorMapper].
+func (a Adapter) AdaptFuncE(next HandlerFuncE) http.HandlerFunc {
+	return a.AdaptFunc(func(w ResponseWriter, r *http.Request) Body {
+		body, err := next(w, r)
+		if err != nil {
+			return a.config.mapError(w, r, err)
+		}
+		return body
+	})
+}
+
 func (a Adapter) Adapt(next Handler) http.Handler {
 	return a.AdaptFunc(next.ServeHTTP)
 }
@@ -3,11 +3,15 @@ package rsvp_test
 import (
 	"bytes"
 	"compress/flate"
+	"errors"
 	"fmt"
+	html "html/template"
 	"io"
+	"iter"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"syscall"
 	"testing"
 
 	"github.com/Teajey/rsvp"
@@ -112,3 +116,217 @@ func TestWithCompressionMiddleware(t *testing.T) {
 	assert.Eq(t, "status code", status, 200)
 	assert.Eq(t, "expected body", reqBody, respBody)
 }
+
+type brokenPipeWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (brokenPipeWriter) Write([]byte) (int, error) {
+	return 0, syscall.EPIPE
+}
+
+func serveWithErrorHook(t *testing.T, cfg rsvp.Config, w http.ResponseWriter, req *http.Request, handler rsvp.HandlerFunc) *rsvp.WriteError {
+	t.Helper()
+	var got *rsvp.WriteError
+	cfg.OnError = func(r *http.Request, err *rsvp.WriteError) {
+		assert.Eq(t, "hook request", req, r)
+		got = err
+	}
+	rsvp.NewAdapter(cfg).AdaptFunc(handler).ServeHTTP(w, req)
+	if got == nil {
+		t.Fatal("OnError was not called")
+	}
+	return got
+}
+
+func TestOnErrorTemplate(t *testing.T) {
+	cfg := rsvp.Config{}
+	cfg.HtmlTemplate = html.Must(html.New("tm").Parse(`<div>{{.NonExistent}}</div>`))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+
+	err := serveWithErrorHook(t, cfg, httptest.NewRecorder(), req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Body{Data: "Hello", TemplateName: "tm"}.StatusAccepted()
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorTemplate, err.Kind)
+	assert.Eq(t, "media type", "text/html", err.MediaType)
+	assert.Eq(t, "status", http.StatusAccepted, err.Status)
+}
+
+func TestOnErrorEncoding(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+
+	err := serveWithErrorHook(t, rsvp.Config{}, httptest.NewRecorder(), req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(map[string]any{"ch": make(chan int)})
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorEncoding, err.Kind)
+	assert.Eq(t, "media type", "application/json", err.MediaType)
+	assert.Eq(t, "status", http.StatusOK, err.Status)
+}
+
+func TestOnErrorClientDisconnect(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	w := brokenPipeWriter{httptest.NewRecorder()}
+
+	err := serveWithErrorHook(t, rsvp.Config{}, w, req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data("Hello")
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorClientDisconnect, err.Kind)
+	assert.True(t, "wraps the write error", errors.Is(err, syscall.EPIPE))
+}
+
+func TestOnErrorClientDisconnectBuffered(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	w := brokenPipeWriter{httptest.NewRecorder()}
+
+	err := serveWithErrorHook(t, rsvp.Config{Buffered: true}, w, req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data("Hello")
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorClientDisconnect, err.Kind)
+	assert.Eq(t, "media type", "text/plain", err.MediaType)
+}
+
+func TestOnErrorUnsupportedMediaType(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+
+	err := serveWithErrorHook(t, rsvp.Config{}, httptest.NewRecorder(), req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		w.Header().Set("Content-Type", "text/csv")
+		return rsvp.Data("Hello")
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorUnsupportedMediaType, err.Kind)
+	assert.Eq(t, "media type", "text/csv", err.MediaType)
+}
+
+type panickingJson struct{}
+
+func (panickingJson) MarshalJSON() ([]byte, error) {
+	panic("cannot marshal")
+}
+
+func servePanic(t *testing.T, cfg rsvp.Config, req *http.Request, handler rsvp.HandlerFunc) (*httptest.ResponseRecorder, *rsvp.PanicError) {
+	t.Helper()
+	var got *rsvp.PanicError
+	cfg.OnPanic = func(r *http.Request, err *rsvp.PanicError) {
+		got = err
+	}
+	rec := httptest.NewRecorder()
+	rsvp.NewAdapter(cfg).AdaptFunc(handler).ServeHTTP(rec, req)
+	if got == nil {
+		t.Fatal("OnPanic was not called")
+	}
+	return rec, got
+}
+
+func panickingHandler(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+	w.DefaultTemplateName("page")
+	w.Header().Set("Location", "/elsewhere")
+	panic("something went wrong")
+}
+
+func TestPanicRendersNegotiatedProblem(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+
+	rec, perr := servePanic(t, rsvp.Config{}, req, panickingHandler)
+
+	assert.Eq(t, "panic value", any("something went wrong"), perr.Value)
+	assert.True(t, "stack includes the handler", bytes.Contains(perr.Stack, []byte("panickingHandler")))
+	assert.Eq(t, "status code", http.StatusInternalServerError, rec.Code)
+	assert.Eq(t, "content type", "application/json", rec.Header().Get("Content-Type"))
+	assert.Eq(t, "location", "", rec.Header().Get("Location"))
+	assert.Eq(t, "body", `{"status":500,"title":"Internal Server Error"}`+"\n", rec.Body.String())
+}
+
+func TestPanicRendersProblemTemplate(t *testing.T) {
+	cfg := rsvp.Config{ProblemTemplateName: "problem"}
+	cfg.HtmlTemplate = html.Must(html.New("page").Parse(`<p>{{.}}</p>`))
+	cfg.HtmlTemplate = html.Must(cfg.HtmlTemplate.New("problem").Parse(`<h1>{{.Title}}</h1>`))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+
+	rec, _ := servePanic(t, cfg, req, panickingHandler)
+
+	assert.Eq(t, "status code", http.StatusInternalServerError, rec.Code)
+	assert.Eq(t, "content type", "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Eq(t, "body", `<h1>Internal Server Error</h1>`, rec.Body.String())
+}
+
+// servePanicAborted is like servePanic, for a handler that panics after the status code has been written, which aborts the response.
+func servePanicAborted(t *testing.T, req *http.Request, handler rsvp.HandlerFunc) (rec *httptest.ResponseRecorder, perr *rsvp.PanicError) {
+	t.Helper()
+	cfg := rsvp.Config{OnPanic: func(r *http.Request, err *rsvp.PanicError) {
+		perr = err
+	}}
+	rec = httptest.NewRecorder()
+	defer func() {
+		assert.Eq(t, "recovered", any(http.ErrAbortHandler), recover())
+		if perr == nil {
+			t.Fatal("OnPanic was not called")
+		}
+	}()
+	rsvp.NewAdapter(cfg).AdaptFunc(handler).ServeHTTP(rec, req)
+	return rec, perr
+}
+
+func TestPanicAfterHeaderAbortsResponse(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+
+	rec, perr := servePanicAborted(t, req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(panickingJson{})
+	})
+
+	assert.Eq(t, "panic value", any("cannot marshal"), perr.Value)
+	assert.Eq(t, "status code", http.StatusOK, rec.Code)
+	assert.Eq(t, "body", "", rec.Body.String())
+}
+
+func TestPanicMidRenderAbortsResponse(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	rec, perr := servePanicAborted(t, req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(iter.Seq[int](func(yield func(int) bool) {
+			if !yield(1) {
+				return
+			}
+			panic("lost the connection to the database")
+		}))
+	})
+
+	assert.Eq(t, "panic value", any("lost the connection to the database"), perr.Value)
+	assert.Eq(t, "status code", http.StatusOK, rec.Code)
+	assert.Eq(t, "body written before the panic", "1\n", rec.Body.String())
+}
+
+func TestPanicWhileBufferingRendersProblem(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+
+	rec, _ := servePanic(t, rsvp.Config{Buffered: true}, req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(panickingJson{})
+	})
+
+	assert.Eq(t, "status code", http.StatusInternalServerError, rec.Code)
+	assert.Eq(t, "content type", "application/json", rec.Header().Get("Content-Type"))
+}
+
+func TestAbortHandlerPanicIsNotRecovered(t *testing.T) {
+	called := false
+	cfg := rsvp.Config{OnPanic: func(r *http.Request, err *rsvp.PanicError) { called = true }}
+	handler := rsvp.NewAdapter(cfg).AdaptFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		assert.Eq(t, "recovered", any(http.ErrAbortHandler), recover())
+		assert.Eq(t, "OnPanic called", false, called)
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
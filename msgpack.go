@@ -5,33 +5,44 @@ package rsvp
 import (
 	"fmt"
 	"io"
+	"net/http"
 
-	"github.com/Teajey/rsvp/internal/dev"
 	msgpack "github.com/vmihailenco/msgpack/v5"
 )
 
 const SupportedMediaTypeMsgpack string = "application/vnd.msgpack"
 
-func init() {
-	mediaTypeToContentType[SupportedMediaTypeMsgpack] = "application/vnd.msgpack"
+// MsgpackCodec renders any [Body.Data] as application/vnd.msgpack.
+//
+// It is only built with -tags=rsvp_msgpack, and is not offered by default. Register it with [WithMsgpack] or [Config.Codecs].
+type MsgpackCodec struct{}
 
-	extToProposalMap["msgpack"] = SupportedMediaTypeMsgpack
+// WithMsgpack offers application/vnd.msgpack via [MsgpackCodec].
+func WithMsgpack() Option {
+	return WithCodecs(MsgpackCodec{})
+}
 
-	mediaTypeExtensionHandlers = append(mediaTypeExtensionHandlers, msgpackHandler)
+func (MsgpackCodec) MediaType() string    { return SupportedMediaTypeMsgpack }
+func (MsgpackCodec) ContentType() string  { return "application/vnd.msgpack" }
+func (MsgpackCodec) Extensions() []string { return []string{"msgpack"} }
 
-	extendedMediaTypes = append(extendedMediaTypes, SupportedMediaTypeMsgpack)
+func (MsgpackCodec) CanEncode(res *Body, cfg Config) bool {
+	return true
 }
 
-func msgpackHandler(mediaType string, w io.Writer, res *Body) (bool, error) {
-	if mediaType != SupportedMediaTypeMsgpack {
-		return false, nil
-	}
-
-	dev.Log("Rendering msgpack...")
+func (MsgpackCodec) Encode(w io.Writer, res *Body, cfg Config) error {
 	err := msgpack.NewEncoder(w).Encode(res.Data)
 	if err != nil {
-		return true, fmt.Errorf("rendering data as msgpack: %w", err)
+		return fmt.Errorf("rendering data as msgpack: %w", err)
 	}
 
-	return true, nil
+	return nil
+}
+
+func (MsgpackCodec) Decode(r *http.Request, v any, cfg Config) error {
+	err := msgpack.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("decoding msgpack request body: %w", err)
+	}
+	return nil
 }
@@ -3,12 +3,15 @@
 package rsvp_test
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/Teajey/rsvp"
 	"github.com/Teajey/rsvp/internal/assert"
+	msgpack "github.com/vmihailenco/msgpack/v5"
 )
 
 func TestRequestMsgpackInteger(t *testing.T) {
@@ -17,7 +20,7 @@ func TestRequestMsgpackInteger(t *testing.T) {
 	req.Header.Set("Accept", "application/vnd.msgpack")
 	rec := httptest.NewRecorder()
 
-	err := makeHandler(res, rsvp.Config{})(rec, req)
+	err := makeHandler(res, rsvp.NewAdapter(rsvp.Config{}, rsvp.WithMsgpack()).Config())(rec, req)
 	assert.FatalErr(t, "handler", err)
 
 	resp := rec.Result()
@@ -32,7 +35,7 @@ func TestRequestMsgpackEmptyMapUsingFileExtension(t *testing.T) {
 	req := httptest.NewRequest("GET", "/resource.msgpack", nil)
 	rec := httptest.NewRecorder()
 
-	err := makeHandler(res, rsvp.Config{})(rec, req)
+	err := makeHandler(res, rsvp.NewAdapter(rsvp.Config{}, rsvp.WithMsgpack()).Config())(rec, req)
 	assert.FatalErr(t, "handler", err)
 
 	resp := rec.Result()
@@ -41,3 +44,73 @@ func TestRequestMsgpackEmptyMapUsingFileExtension(t *testing.T) {
 	body := rec.Body.Bytes()
 	assert.SlicesEq(t, "body contents", []byte{0x80}, body)
 }
+
+func TestMsgpackIsNotOfferedByDefault(t *testing.T) {
+	res := rsvp.Body{Data: 2}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/vnd.msgpack")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusNotAcceptable, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/json", resp.Header.Get("Content-Type"))
+}
+
+func TestAdaptersWithAndWithoutMsgpack(t *testing.T) {
+	cfg := rsvp.Config{}
+	with := rsvp.NewAdapter(cfg, rsvp.WithMsgpack())
+	without := rsvp.NewAdapter(cfg)
+
+	handler := func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(2)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/vnd.msgpack")
+
+	rec := httptest.NewRecorder()
+	with.AdaptFunc(handler).ServeHTTP(rec, req)
+	assert.Eq(t, "Status code with msgpack", http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	without.AdaptFunc(handler).ServeHTTP(rec, req)
+	assert.Eq(t, "Status code without msgpack", http.StatusNotAcceptable, rec.Code)
+
+	assert.Eq(t, "Original config is untouched", 0, len(cfg.Codecs))
+}
+
+func TestStructResponseWithMsgpack(t *testing.T) {
+	cfg := rsvp.NewAdapter(rsvp.Config{HtmlTemplate: htmlTemplate}, rsvp.WithMsgpack()).Config()
+	resp := rsvp.Body{Data: struct{}{}, TemplateName: "tm"}
+	actual := slices.Collect(resp.MediaTypes(cfg))
+
+	expected := []string{
+		rsvp.SupportedMediaTypeJson,
+		rsvp.SupportedMediaTypeXml,
+		rsvp.SupportedMediaTypeHtml,
+		rsvp.SupportedMediaTypeMsgpack,
+	}
+
+	assert.SlicesEq(t, "media types", expected, actual)
+}
+
+func TestDecodeMsgpack(t *testing.T) {
+	data, err := msgpack.Marshal(decodedUser{Name: "Tea", Email: "tea@example.com"})
+	assert.FatalErr(t, "marshal", err)
+	req := httptest.NewRequest("POST", "/users", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/vnd.msgpack")
+
+	var user decodedUser
+	err = rsvp.Decode(req, rsvp.Config{}, &user)
+	var unsupported *rsvp.UnsupportedMediaTypeError
+	assert.FatalErrAs(t, "msgpack is not enabled", err, &unsupported)
+
+	req = httptest.NewRequest("POST", "/users", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/vnd.msgpack")
+	err = rsvp.Decode(req, rsvp.NewAdapter(rsvp.Config{}, rsvp.WithMsgpack()).Config(), &user)
+	assert.FatalErr(t, "decode", err)
+	assert.Eq(t, "name", "Tea", user.Name)
+}
@@ -0,0 +1,131 @@
+package rsvp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+const (
+	SupportedMediaTypeNdjson  string = "application/x-ndjson"
+	SupportedMediaTypeJsonl   string = "application/jsonl"
+	SupportedMediaTypeJsonSeq string = "application/json-seq"
+)
+
+// canEncodeJsonRecords reports whether data is a collection of records: a slice, array or stream, but not []byte.
+func canEncodeJsonRecords(data any) bool {
+	if _, ok := asStream(data); ok {
+		return true
+	}
+	v := reflect.ValueOf(data)
+	if !v.IsValid() {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return v.Type().Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// encodeJsonRecords writes each record of data with marshal, between before and after, and flushes w after each one.
+func encodeJsonRecords(ctx context.Context, w io.Writer, data any, marshal func(any) ([]byte, error), before, after string) error {
+	write := func(record reflect.Value) error {
+		b, err := marshal(record.Interface())
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, before); err != nil {
+			return err
+		}
+		if _, err = w.Write(b); err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, after); err != nil {
+			return err
+		}
+		return flushWriter(w)
+	}
+
+	if s, ok := asStream(data); ok {
+		return s.each(ctx, func() error { return nil }, write)
+	}
+	v := reflect.ValueOf(data)
+	for i := range v.Len() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := write(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeJsonLines writes a line of compact JSON for each record of res.Data. [Config.JsonPrefix] and [Config.JsonIndent] are not used, because they would break records across lines.
+func encodeJsonLines(w io.Writer, res *Body) error {
+	return encodeJsonRecords(res.Context(), w, res.Data, json.Marshal, "", "\n")
+}
+
+type ndjsonCodec struct{}
+
+func (ndjsonCodec) MediaType() string    { return SupportedMediaTypeNdjson }
+func (ndjsonCodec) ContentType() string  { return "application/x-ndjson" }
+func (ndjsonCodec) Extensions() []string { return []string{"ndjson"} }
+
+func (ndjsonCodec) CanEncode(res *Body, cfg Config) bool {
+	return canEncodeJsonRecords(res.Data)
+}
+
+func (ndjsonCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	err := encodeJsonLines(w, res)
+	if err != nil {
+		return fmt.Errorf("rendering data as newline-delimited JSON: %w", err)
+	}
+	return nil
+}
+
+type jsonlCodec struct{}
+
+func (jsonlCodec) MediaType() string    { return SupportedMediaTypeJsonl }
+func (jsonlCodec) ContentType() string  { return "application/jsonl" }
+func (jsonlCodec) Extensions() []string { return []string{"jsonl"} }
+
+func (jsonlCodec) CanEncode(res *Body, cfg Config) bool {
+	return canEncodeJsonRecords(res.Data)
+}
+
+func (jsonlCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	err := encodeJsonLines(w, res)
+	if err != nil {
+		return fmt.Errorf("rendering data as JSON Lines: %w", err)
+	}
+	return nil
+}
+
+// jsonSeqCodec writes https://www.rfc-editor.org/rfc/rfc7464 JSON text sequences, whose records are each preceded by a record separator. Records may span lines, so [Config.JsonPrefix] and [Config.JsonIndent] are used.
+type jsonSeqCodec struct{}
+
+func (jsonSeqCodec) MediaType() string    { return SupportedMediaTypeJsonSeq }
+func (jsonSeqCodec) ContentType() string  { return "application/json-seq" }
+func (jsonSeqCodec) Extensions() []string { return nil }
+
+func (jsonSeqCodec) CanEncode(res *Body, cfg Config) bool {
+	return canEncodeJsonRecords(res.Data)
+}
+
+func (jsonSeqCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	marshal := json.Marshal
+	if cfg.JsonPrefix != "" || cfg.JsonIndent != "" {
+		marshal = func(v any) ([]byte, error) {
+			return json.MarshalIndent(v, cfg.JsonPrefix, cfg.JsonIndent)
+		}
+	}
+	err := encodeJsonRecords(res.Context(), w, res.Data, marshal, "\x1e", "\n")
+	if err != nil {
+		return fmt.Errorf("rendering data as a JSON text sequence: %w", err)
+	}
+	return nil
+}
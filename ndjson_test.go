@@ -0,0 +1,69 @@
+package rsvp_test
+
+import (
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+func renderRecords(t *testing.T, path, accept string, data any, cfg rsvp.Config) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Accept", accept)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data(data), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+	assert.Eq(t, "status", 200, rec.Code)
+
+	return rec
+}
+
+func TestNdjsonSlice(t *testing.T) {
+	rows := []streamRow{{1, "a"}, {2, "b"}}
+	cfg := rsvp.Config{JsonPrefix: "> ", JsonIndent: "  "}
+
+	rec := renderRecords(t, "/rows.ndjson", "", rows, cfg)
+
+	assert.Eq(t, "content type", "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Eq(t, "body is not indented", `{"id":1,"name":"a"}`+"\n"+`{"id":2,"name":"b"}`+"\n", rec.Body.String())
+	assert.True(t, "flushed", rec.Flushed)
+}
+
+func TestJsonlSeq(t *testing.T) {
+	rec := renderRecords(t, "/rows.jsonl", "", streamRows(2), rsvp.Config{})
+
+	assert.Eq(t, "content type", "application/jsonl", rec.Header().Get("Content-Type"))
+	assert.Eq(t, "body", `{"id":1,"name":"a"}`+"\n"+`{"id":2,"name":"b"}`+"\n", rec.Body.String())
+}
+
+func TestJsonSeq(t *testing.T) {
+	cfg := rsvp.Config{JsonIndent: "  "}
+
+	rec := renderRecords(t, "/rows", "application/json-seq", streamRows(2), cfg)
+
+	assert.Eq(t, "content type", "application/json-seq", rec.Header().Get("Content-Type"))
+	expected := "\x1e{\n  \"id\": 1,\n  \"name\": \"a\"\n}\n\x1e{\n  \"id\": 2,\n  \"name\": \"b\"\n}\n"
+	assert.Eq(t, "body is indented", expected, rec.Body.String())
+}
+
+func TestJsonRecordsAreOnlyOfferedForCollections(t *testing.T) {
+	cases := map[string]any{
+		"struct": streamRow{},
+		"bytes":  []byte("hello"),
+		"string": "hello",
+		"map":    map[string]int{"a": 1},
+	}
+
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			res := rsvp.Data(data)
+			mediaTypes := slices.Collect(res.MediaTypes(rsvp.Config{}))
+			assert.True(t, "ndjson is not offered", !slices.Contains(mediaTypes, rsvp.SupportedMediaTypeNdjson))
+			assert.True(t, "json-seq is not offered", !slices.Contains(mediaTypes, rsvp.SupportedMediaTypeJsonSeq))
+		})
+	}
+}
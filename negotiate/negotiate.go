@@ -0,0 +1,222 @@
+// Package negotiate implements proactive content negotiation as specified by https://httpwg.org/specs/rfc9110.html#proactive.negotiation
+//
+// It understands the Accept, Accept-Language, Accept-Encoding and Accept-Charset headers.
+// Media ranges (e.g. text/*) are matched by type, subtype and parameters.
+// Anything else is matched as a token or language range (e.g. en matches en-GB).
+package negotiate
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"strings"
+)
+
+func splitAndTrimSpace(s, sep string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, split := range strings.Split(s, sep) {
+			if !yield(strings.TrimSpace(split)) {
+				return
+			}
+		}
+	}
+}
+
+func parseUnsorted(header string) iter.Seq[Proposal] {
+	return func(yield func(Proposal) bool) {
+		index := 0
+		for proposal := range splitAndTrimSpace(header, ",") {
+			parsed, err := ParseProposal(proposal)
+			if err != nil {
+				continue
+			}
+			parsed.index = index
+			index++
+			if !yield(parsed) {
+				return
+			}
+		}
+	}
+}
+
+// precedenceCmp orders proposals by weight, then specificity, then their position in the header.
+//
+// No two proposals from the same header compare as equal, so the order is always deterministic.
+func precedenceCmp(a, b Proposal) int {
+	return cmp.Or(
+		cmp.Compare(b.Weight, a.Weight),
+		cmp.Compare(b.Specificity(), a.Specificity()),
+		cmp.Compare(a.index, b.index),
+	)
+}
+
+// Header is a parsed content negotiation header, sorted by precedence.
+//
+// Proposals with a weight of 0 are kept, because they mark offers as "not acceptable".
+type Header []Proposal
+
+// Parse returns the proposals of header in order of precedence, starting with the highest.
+//
+// Precedence is decided by weight, then specificity, then position in the header.
+//
+// An empty header is equivalent to "*", which accepts anything.
+//
+// Invalid proposals are dropped.
+func Parse(header string) Header {
+	if strings.TrimSpace(header) == "" {
+		header = "*"
+	}
+
+	list := Header(slices.Collect(parseUnsorted(header)))
+	slices.SortFunc(list, precedenceCmp)
+
+	return list
+}
+
+// ParseAccept is equivalent to [Parse], except that an empty header is equivalent to "*/*", as per https://httpwg.org/specs/rfc9110.html#field.accept
+func ParseAccept(accept string) Header {
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+
+	return Parse(accept)
+}
+
+// Values yields the acceptable values of the header, starting with the highest precedence.
+//
+// Values with a weight of 0 are skipped.
+func (h Header) Values() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, p := range h {
+			if p.Weight == 0 {
+				continue
+			}
+			if !yield(p.Value) {
+				return
+			}
+		}
+	}
+}
+
+// match finds the most specific proposal that matches offer, returning its position within h, or -1 if nothing matches.
+//
+// If proposals are equally specific, the one that appears first in the header is used.
+func (h Header) match(offer Proposal) int {
+	found := -1
+	for i, p := range h {
+		if !p.Matches(offer) {
+			continue
+		}
+		if found == -1 {
+			found = i
+			continue
+		}
+		f := h[found]
+		if p.Specificity() > f.Specificity() || (p.Specificity() == f.Specificity() && p.index < f.index) {
+			found = i
+		}
+	}
+	return found
+}
+
+// Match returns the proposal that decides the quality of offer. ok is false if no proposal matches.
+func (h Header) Match(offer string) (p Proposal, ok bool) {
+	o, err := ParseProposal(offer)
+	if err != nil {
+		return Proposal{}, false
+	}
+	i := h.match(o)
+	if i == -1 {
+		return Proposal{}, false
+	}
+	return h[i], true
+}
+
+// Quality returns the weight that the header assigns to offer, which is 0 if offer is not acceptable.
+//
+// As per https://httpwg.org/specs/rfc9110.html#field.accept-encoding the "identity" offer is acceptable unless it is explicitly excluded.
+func (h Header) Quality(offer string) float32 {
+	p, ok := h.Match(offer)
+	if !ok {
+		if strings.EqualFold(offer, "identity") {
+			return 1
+		}
+		return 0
+	}
+	return p.Weight
+}
+
+// Offer is a value that the server is able to provide, weighted by its server-side quality.
+type Offer struct {
+	Value string
+	// Quality is the server-side quality of Value, between 0 and 1, similar to the "qs" parameter of Apache's type maps.
+	// It is multiplied with the weight of the proposal that matches Value. An Offer with a Quality of 0 is never chosen.
+	Quality float32
+}
+
+// Choose returns the acceptable offer with the highest quality.
+//
+// Ties are broken by the specificity of the matching proposal, then its position in the header, and then the order of offers.
+// ok is false if none of offers are acceptable.
+func (h Header) Choose(offers []string) (chosen string, ok bool) {
+	weighted := make([]Offer, len(offers))
+	for i, offer := range offers {
+		weighted[i] = Offer{Value: offer, Quality: 1}
+	}
+	o, ok := h.ChooseOffer(weighted)
+	return o.Value, ok
+}
+
+// ChooseOffer returns the offer with the highest combined quality, which is the weight of its matching proposal multiplied by [Offer.Quality].
+//
+// Ties are broken by the specificity of the matching proposal, then its position in the header, and then the order of offers.
+// ok is false if none of offers are acceptable.
+func (h Header) ChooseOffer(offers []Offer) (chosen Offer, ok bool) {
+	var bestQuality float32
+	best := len(h)
+	var identity *Offer
+	for _, offer := range offers {
+		if offer.Quality <= 0 {
+			continue
+		}
+		o, err := ParseProposal(offer.Value)
+		if err != nil {
+			continue
+		}
+
+		i := h.match(o)
+		if i == -1 {
+			if identity == nil && strings.EqualFold(offer.Value, "identity") {
+				identity = &offer
+			}
+			continue
+		}
+
+		quality := h[i].Weight * offer.Quality
+		if quality <= 0 {
+			continue
+		}
+
+		// h is sorted by precedence, so a lower position wins ties
+		if !ok || quality > bestQuality || (quality == bestQuality && i < best) {
+			bestQuality = quality
+			best = i
+			chosen = offer
+			ok = true
+		}
+	}
+
+	// identity is acceptable unless explicitly excluded, but never outranks a matched proposal
+	if !ok && identity != nil {
+		return *identity, true
+	}
+
+	return
+}
+
+// Negotiate chooses the offer that best satisfies header, which may be the value of any of Accept, Accept-Language, Accept-Encoding or Accept-Charset.
+//
+// ok is false if none of offers are acceptable. If header is empty, the first offer is chosen.
+func Negotiate(offers []string, header string) (chosen string, ok bool) {
+	return Parse(header).Choose(offers)
+}
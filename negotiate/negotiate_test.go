@@ -0,0 +1,223 @@
+package negotiate_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/Teajey/rsvp/internal/assert"
+	"github.com/Teajey/rsvp/negotiate"
+)
+
+func TestParseAccept(t *testing.T) {
+	mediaTypes := slices.Collect(negotiate.ParseAccept("text/*,application/xml, text/html;q=1, text/plain;format=flowed, */*,application/json;q=0.5").Values())
+	expected := []string{
+		"text/plain",
+		"application/xml",
+		"text/html",
+		"text/*",
+		"*/*",
+		"application/json",
+	}
+	assert.Eq(t, "Expected length", 6, len(mediaTypes))
+	for i := range mediaTypes {
+		assert.Eq(t, fmt.Sprintf("Index %d", i), expected[i], mediaTypes[i])
+	}
+}
+
+func TestFirefoxAcceptHeader(t *testing.T) {
+	sq := negotiate.ParseAccept("text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8").Values()
+	sl := slices.Collect(sq)
+	assert.SlicesEq(t, "firefox accepts", []string{"text/html", "application/xhtml+xml", "application/xml", "*/*"}, sl)
+}
+
+func TestParseAcceptIsDeterministic(t *testing.T) {
+	header := "application/xml, text/html, application/json, text/csv"
+	first := slices.Collect(negotiate.ParseAccept(header).Values())
+	for range 100 {
+		assert.SlicesEq(t, "order", first, slices.Collect(negotiate.ParseAccept(header).Values()))
+	}
+	assert.SlicesEq(t, "header order", []string{"application/xml", "text/html", "application/json", "text/csv"}, first)
+}
+
+func TestParseAcceptSkipsNotAcceptable(t *testing.T) {
+	sl := slices.Collect(negotiate.ParseAccept("application/json;q=0, */*").Values())
+	assert.SlicesEq(t, "acceptable", []string{"*/*"}, sl)
+}
+
+// https://httpwg.org/specs/rfc9110.html#field.accept
+func TestRfcPrecedence(t *testing.T) {
+	sl := slices.Collect(negotiate.ParseAccept("text/*, text/plain, text/plain;format=flowed, */*").Values())
+	assert.SlicesEq(t, "precedence", []string{"text/plain", "text/plain", "text/*", "*/*"}, sl)
+
+	accept := negotiate.ParseAccept("text/*, text/plain, text/plain;format=flowed, */*")
+	assert.Eq(t, "most specific first", "flowed", accept[0].Params["format"])
+}
+
+// https://httpwg.org/specs/rfc9110.html#field.accept
+func TestRfcQuality(t *testing.T) {
+	accept := negotiate.ParseAccept("text/*;q=0.3, text/plain;q=0.7, text/plain;format=flowed, text/plain;format=fixed;q=0.4, */*;q=0.5")
+
+	cases := []struct {
+		offer   string
+		quality float32
+	}{
+		{"text/plain;format=flowed", 1},
+		{"text/plain", 0.7},
+		{"text/html", 0.3},
+		{"image/jpeg", 0.5},
+		{"text/plain;format=fixed", 0.4},
+		{"text/plain;format=other", 0.7},
+	}
+
+	for _, c := range cases {
+		assert.Eq(t, c.offer, c.quality, accept.Quality(c.offer))
+	}
+}
+
+// https://www.rfc-editor.org/rfc/rfc7231#section-5.3.2
+func TestRfcQualityLevels(t *testing.T) {
+	accept := negotiate.ParseAccept("text/*;q=0.3, text/html;q=0.7, text/html;level=1, text/html;level=2;q=0.4, */*;q=0.5")
+
+	cases := []struct {
+		offer   string
+		quality float32
+	}{
+		{"text/html;level=1", 1},
+		{"text/html", 0.7},
+		{"text/plain", 0.3},
+		{"image/jpeg", 0.5},
+		{"text/html;level=2", 0.4},
+		{"text/html;level=3", 0.7},
+	}
+
+	for _, c := range cases {
+		assert.Eq(t, c.offer, c.quality, accept.Quality(c.offer))
+	}
+}
+
+func TestChoose(t *testing.T) {
+	cases := []struct {
+		name   string
+		accept string
+		offers []string
+		chosen string
+		ok     bool
+	}{
+		{"empty header accepts the first offer", "", []string{"application/json", "application/xml"}, "application/json", true},
+		{"wildcard accepts the first offer", "*/*", []string{"application/json", "application/xml"}, "application/json", true},
+		{"application wildcard", "application/*", []string{"text/plain", "application/json"}, "application/json", true},
+		{"zero weight is not acceptable", "application/json;q=0", []string{"application/json"}, "", false},
+		{"zero weight overrides a wildcard", "*/*, application/json;q=0", []string{"application/json", "application/xml"}, "application/xml", true},
+		{"zero weight wildcard is overridden by a specific type", "application/xml, */*;q=0", []string{"application/json", "application/xml"}, "application/xml", true},
+		{"higher weight wins", "application/json;q=0.5, application/xml", []string{"application/json", "application/xml"}, "application/xml", true},
+		{"specific type beats an equally weighted wildcard", "*/*, text/html", []string{"application/json", "text/html"}, "text/html", true},
+		{"header order breaks ties", "application/xml, application/json", []string{"application/json", "application/xml"}, "application/xml", true},
+		{"offer order breaks ties within a range", "application/*", []string{"application/xml", "application/json"}, "application/xml", true},
+		{"parameters must match", "text/html;level=1", []string{"text/html"}, "", false},
+		{"parameters match", "text/html;level=1", []string{"text/html;level=1"}, "text/html;level=1", true},
+		{"charset is ignored if not offered", "application/json; charset=utf-8", []string{"application/json"}, "application/json", true},
+		{"charset matches case insensitively", "text/csv;charset=UTF-8", []string{"text/csv;charset=utf-8"}, "text/csv;charset=utf-8", true},
+		{"charset must match if offered", "text/csv;charset=iso-8859-1", []string{"text/csv;charset=utf-8"}, "", false},
+		{"case insensitive", "Application/JSON", []string{"application/json"}, "application/json", true},
+		{"nothing acceptable", "image/png", []string{"application/json"}, "", false},
+		{"invalid proposals are dropped", "text/, application/json", []string{"text/plain", "application/json"}, "application/json", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			chosen, ok := negotiate.Negotiate(c.offers, c.accept)
+			assert.Eq(t, "chosen", c.chosen, chosen)
+			assert.Eq(t, "ok", c.ok, ok)
+		})
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		offers []string
+		chosen string
+		ok     bool
+	}{
+		{"exact", "da, en-gb;q=0.8, en;q=0.7", []string{"en-GB", "da"}, "da", true},
+		{"weighted", "da;q=0.5, en-gb;q=0.8, en;q=0.7", []string{"da", "en-GB"}, "en-GB", true},
+		{"prefix", "da, en-gb;q=0.8, en;q=0.7", []string{"en-US"}, "en-US", true},
+		{"more specific range wins", "en-gb;q=0, en", []string{"en-GB", "en-AU"}, "en-AU", true},
+		{"prefix must end at a subtag", "en", []string{"eng"}, "", false},
+		{"wildcard", "fr, *;q=0.1", []string{"de"}, "de", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			chosen, ok := negotiate.Negotiate(c.offers, c.header)
+			assert.Eq(t, "chosen", c.chosen, chosen)
+			assert.Eq(t, "ok", c.ok, ok)
+		})
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		offers []string
+		chosen string
+		ok     bool
+	}{
+		{"preferred", "gzip;q=0.5, br", []string{"gzip", "br"}, "br", true},
+		{"identity is implicitly acceptable", "br", []string{"gzip", "identity"}, "identity", true},
+		{"identity is excluded explicitly", "br, identity;q=0", []string{"gzip", "identity"}, "", false},
+		{"identity is excluded by wildcard", "br, *;q=0", []string{"gzip", "identity"}, "", false},
+		{"empty header", "", []string{"gzip", "identity"}, "gzip", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			chosen, ok := negotiate.Negotiate(c.offers, c.header)
+			assert.Eq(t, "chosen", c.chosen, chosen)
+			assert.Eq(t, "ok", c.ok, ok)
+		})
+	}
+}
+
+func TestNegotiateCharset(t *testing.T) {
+	chosen, ok := negotiate.Negotiate([]string{"utf-8", "iso-8859-1"}, "iso-8859-5, ISO-8859-1;q=0.9, utf-8;q=0.8")
+	assert.Eq(t, "ok", true, ok)
+	assert.Eq(t, "chosen", "iso-8859-1", chosen)
+}
+
+func TestMatch(t *testing.T) {
+	p, ok := negotiate.ParseAccept("text/*;q=0.3, text/csv;header=absent;q=0.9").Match("text/csv;header=absent")
+	assert.Eq(t, "ok", true, ok)
+	assert.Eq(t, "matched", "text/csv", p.Value)
+	assert.Eq(t, "header param", "absent", p.Params["header"])
+
+	_, ok = negotiate.ParseAccept("text/*").Match("application/json")
+	assert.Eq(t, "not ok", false, ok)
+}
+
+func TestChooseOffer(t *testing.T) {
+	cases := []struct {
+		name   string
+		accept string
+		offers []negotiate.Offer
+		chosen string
+		ok     bool
+	}{
+		{"server quality decides a wildcard", "*/*", []negotiate.Offer{{"application/json", 0.8}, {"text/html", 1}}, "text/html", true},
+		{"client and server quality are combined", "application/xml, text/html;q=0.8", []negotiate.Offer{{"application/xml", 0.5}, {"text/html", 1}}, "text/html", true},
+		{"client quality still matters", "application/xml, text/html;q=0.4", []negotiate.Offer{{"application/xml", 0.5}, {"text/html", 1}}, "application/xml", true},
+		{"zero server quality is never chosen", "application/xml", []negotiate.Offer{{"application/xml", 0}}, "", false},
+		{"equal combined quality falls back to header precedence", "application/xml, application/json;q=0.5", []negotiate.Offer{{"application/json", 1}, {"application/xml", 0.5}}, "application/xml", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			chosen, ok := negotiate.ParseAccept(c.accept).ChooseOffer(c.offers)
+			assert.Eq(t, "chosen", c.chosen, chosen.Value)
+			assert.Eq(t, "ok", c.ok, ok)
+		})
+	}
+}
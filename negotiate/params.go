@@ -0,0 +1,25 @@
+package negotiate
+
+import (
+	"strings"
+)
+
+func parsePair(p string) (string, string) {
+	pairs := strings.SplitN(p, "=", 2)
+	key := strings.ToLower(strings.TrimSpace(pairs[0]))
+	var value string
+	if len(pairs) > 1 {
+		value = unquote(strings.TrimSpace(pairs[1]))
+	} else {
+		value = ""
+	}
+	return key, value
+}
+
+// unquote removes the quotes of a quoted-string parameter value, i.e. format="flowed"
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	return strings.ReplaceAll(s[1:len(s)-1], `\`, "")
+}
@@ -0,0 +1,212 @@
+package negotiate
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// Proposal is a single element of a content negotiation header, e.g. "text/html;level=1;q=0.8" or "en-GB;q=0.5"
+type Proposal struct {
+	// Value is the lower-cased media range (e.g. text/*), language range (e.g. en-gb) or token (e.g. gzip) being proposed.
+	Value string
+	// Weight is the "q" parameter, between 0 and 1. A Weight of 0 means "not acceptable".
+	Weight float32
+	// Params are the parameters that precede the weight, with lower-cased keys. Any accept-ext parameters following the weight are dropped.
+	Params map[string]string
+
+	// index is the position of the proposal within its header.
+	index int
+}
+
+// IsMediaRange reports whether this proposal has the type/subtype form used by the Accept header.
+func (p Proposal) IsMediaRange() bool {
+	return strings.Contains(p.Value, "/")
+}
+
+// MediaType splits Value into its type and subtype. Both are empty if this is not a media range.
+func (p Proposal) MediaType() (superType string, subType string) {
+	superType, subType, ok := strings.Cut(p.Value, "/")
+	if !ok {
+		return "", ""
+	}
+	return superType, subType
+}
+
+// Specificity ranks how narrowly this proposal matches offers, as per https://httpwg.org/specs/rfc9110.html#field.accept
+//
+// For media ranges, */* is the least specific, followed by type/*, type/subtype, and then type/subtype with each additional parameter.
+//
+// Otherwise, * is the least specific, followed by each subtag of a language range, e.g. en-gb is more specific than en.
+func (p Proposal) Specificity() int {
+	if p.IsMediaRange() {
+		superType, subType := p.MediaType()
+		switch {
+		case superType == "*":
+			return 0
+		case subType == "*":
+			return 1
+		default:
+			return 2 + len(p.Params)
+		}
+	}
+
+	if p.Value == "*" {
+		return 0
+	}
+	return 1 + strings.Count(p.Value, "-")
+}
+
+// Matches reports whether this proposal includes offer.
+//
+// Media ranges must match on type and subtype, and every parameter of this proposal must be present with the same value in offer.
+// The exception is charset, which is only compared if offer declares one, because most servers do not vary on it.
+// Other values match if they are equal, or if offer begins with this proposal followed by "-", as per the basic filtering of https://www.rfc-editor.org/rfc/rfc4647#section-3.3.1
+func (p Proposal) Matches(offer Proposal) bool {
+	if p.Value == "*" || p.Value == "*/*" {
+		return p.paramsMatch(offer)
+	}
+
+	if p.IsMediaRange() {
+		superType, subType := p.MediaType()
+		offerSuper, offerSub := offer.MediaType()
+		if superType != offerSuper {
+			return false
+		}
+		if subType != "*" && subType != offerSub {
+			return false
+		}
+		return p.paramsMatch(offer)
+	}
+
+	return p.Value == offer.Value || strings.HasPrefix(offer.Value, p.Value+"-")
+}
+
+func (p Proposal) paramsMatch(offer Proposal) bool {
+	for k, v := range p.Params {
+		ov, ok := offer.Params[k]
+		if !ok && k == "charset" {
+			continue
+		}
+		if !ok || !paramValuesEqual(k, v, ov) {
+			return false
+		}
+	}
+	return true
+}
+
+func paramValuesEqual(key, a, b string) bool {
+	if key == "charset" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+var ErrorProposalEmpty = errors.New("empty proposal")
+var ErrorProposalEmptySuper = errors.New("empty media supertype, i.e. <supertype>/<subtype>")
+var ErrorProposalEmptySub = errors.New("empty media subtype, i.e. <supertype>/<subtype>")
+var ErrorProposalWildSuper = errors.New("supertype may not be wild on it's own, i.e. */<subtype>")
+
+type ErrorProposalBadWeightFloat struct {
+	Err error
+}
+
+func (e ErrorProposalBadWeightFloat) Error() string {
+	return fmt.Sprintf("couldn't parse weight as float32: %s", e.Err)
+}
+
+func (e ErrorProposalBadWeightFloat) Unwrap() error {
+	return e.Err
+}
+
+// ParseProposal parses a single element of a content negotiation header.
+func ParseProposal(s string) (Proposal, error) {
+	nextElem, stopElem := iter.Pull(splitAndTrimSpace(s, ";"))
+	defer stopElem()
+	value, _ := nextElem()
+	if value == "" {
+		return Proposal{}, ErrorProposalEmpty
+	}
+
+	value, err := parseValue(value)
+	if err != nil {
+		return Proposal{}, err
+	}
+
+	proposal := Proposal{
+		Value:  value,
+		Weight: 1.,
+	}
+
+	for {
+		paramStr, ok := nextElem()
+		if !ok {
+			return proposal, nil
+		}
+		if paramStr == "" {
+			continue
+		}
+
+		key, value := parsePair(paramStr)
+		if key != "q" {
+			if proposal.Params == nil {
+				proposal.Params = make(map[string]string)
+			}
+			proposal.Params[key] = value
+			continue
+		}
+
+		weight, err := parseWeight(value)
+		if err != nil {
+			return proposal, err
+		}
+		proposal.Weight = weight
+
+		// Anything after the weight is an accept-ext, which has no bearing on matching
+		return proposal, nil
+	}
+}
+
+func parseValue(value string) (string, error) {
+	if !strings.Contains(value, "/") {
+		return strings.ToLower(value), nil
+	}
+
+	nextType, stopType := iter.Pull(splitAndTrimSpace(value, "/"))
+	defer stopType()
+	superType, _ := nextType()
+	if superType == "" {
+		return "", ErrorProposalEmptySuper
+	}
+	subType, ok := nextType()
+	if !ok || subType == "" {
+		return "", ErrorProposalEmptySub
+	}
+	if superType == "*" && subType != "*" {
+		return "", ErrorProposalWildSuper
+	}
+
+	return strings.ToLower(superType + "/" + subType), nil
+}
+
+func parseWeight(weighting string) (float32, error) {
+	if len(weighting) > 5 {
+		return 0, ErrorProposalBadWeightFloat{errors.New("weight is unlikely to be valid because it contains more than 5 characters")}
+	}
+
+	val, err := strconv.ParseFloat(weighting, 32)
+	if err != nil {
+		return 0, ErrorProposalBadWeightFloat{err}
+	}
+
+	switch {
+	case val > 1:
+		return 1., nil
+	case val < 0:
+		return 0., nil
+	default:
+		return float32(val), nil
+	}
+}
@@ -0,0 +1,113 @@
+package negotiate_test
+
+import (
+	"testing"
+
+	"github.com/Teajey/rsvp/internal/assert"
+	"github.com/Teajey/rsvp/negotiate"
+)
+
+func TestParseProposalHtml(t *testing.T) {
+	proposal, err := negotiate.ParseProposal("text/html")
+	assert.FatalErr(t, "Parsing proposal", err)
+	assert.Eq(t, "MediaType value", proposal.Value, "text/html")
+	assert.Eq(t, "Weight value", proposal.Weight, 1.)
+}
+
+func TestParseProposalHtmlWeighted(t *testing.T) {
+	proposal, err := negotiate.ParseProposal("text/html;q=0.8")
+	assert.FatalErr(t, "Parsing proposal", err)
+	assert.Eq(t, "MediaType value", proposal.Value, "text/html")
+	assert.Eq(t, "Weight value", proposal.Weight, 0.8)
+}
+
+func TestParseProposalHtmlWeirdSpacing(t *testing.T) {
+	proposal, err := negotiate.ParseProposal(" text / html ; q = 0.8 ")
+	assert.FatalErr(t, "Parsing proposal", err)
+	assert.Eq(t, "MediaType value", proposal.Value, "text/html")
+	assert.Eq(t, "Weight value", proposal.Weight, 0.8)
+}
+
+func TestParseProposalEmpty(t *testing.T) {
+	_, err := negotiate.ParseProposal("")
+	assert.FatalErrIs(t, "Parsing proposal", err, negotiate.ErrorProposalEmpty)
+}
+
+func TestParseProposalEmptySuper(t *testing.T) {
+	_, err := negotiate.ParseProposal("/html")
+	assert.FatalErrIs(t, "Parsing proposal", err, negotiate.ErrorProposalEmptySuper)
+}
+
+func TestParseProposalEmptySub(t *testing.T) {
+	_, err := negotiate.ParseProposal("text/")
+	assert.FatalErrIs(t, "Parsing proposal", err, negotiate.ErrorProposalEmptySub)
+}
+
+func TestParseProposalBadWeightPrefix(t *testing.T) {
+	proposal, err := negotiate.ParseProposal("text/html;w=0.8")
+	assert.FatalErr(t, "Parsing proposal", err)
+	assert.Eq(t, "MediaType value", proposal.Value, "text/html")
+	assert.Eq(t, "Weight value", proposal.Weight, 1.)
+}
+
+func TestParseProposalBadWeightFloat(t *testing.T) {
+	_, err := negotiate.ParseProposal("text/html;q=*")
+	var badWeightFloat negotiate.ErrorProposalBadWeightFloat
+	assert.FatalErrAs(t, "Parsing proposal", err, &badWeightFloat)
+}
+
+func TestParseProposalDouble(t *testing.T) {
+	_, err := negotiate.ParseProposal("text/html;q=0.8,text/plain;q=0.7")
+	var badWeightFloat negotiate.ErrorProposalBadWeightFloat
+	assert.FatalErrAs(t, "Parsing proposal", err, &badWeightFloat)
+}
+
+func TestParseProposalWild(t *testing.T) {
+	proposal, err := negotiate.ParseProposal("*/*;q=0.8")
+	assert.FatalErr(t, "Parsing proposal", err)
+	assert.Eq(t, "MediaType value", proposal.Value, "*/*")
+	assert.Eq(t, "Weight value", proposal.Weight, 0.8)
+}
+
+func TestParseProposalWildSuper(t *testing.T) {
+	_, err := negotiate.ParseProposal("*/html;q=0.8")
+	assert.FatalErrIs(t, "Parsing proposal", err, negotiate.ErrorProposalWildSuper)
+}
+
+func TestParseProposalWildSub(t *testing.T) {
+	proposal, err := negotiate.ParseProposal("text/*;q=0.8")
+	assert.FatalErr(t, "Parsing proposal", err)
+	assert.Eq(t, "MediaType value", proposal.Value, "text/*")
+	assert.Eq(t, "Weight value", proposal.Weight, 0.8)
+}
+
+func TestParseProposalOverWeighted(t *testing.T) {
+	proposal, err := negotiate.ParseProposal("text/html;q=1.1")
+	assert.FatalErr(t, "Parsing proposal", err)
+	assert.Eq(t, "MediaType value", proposal.Value, "text/html")
+	assert.Eq(t, "Weight value", proposal.Weight, 1)
+}
+
+func TestParseProposalUnderWeighted(t *testing.T) {
+	proposal, err := negotiate.ParseProposal("text/html;q=-0.1")
+	assert.FatalErr(t, "Parsing proposal", err)
+	assert.Eq(t, "MediaType value", proposal.Value, "text/html")
+	assert.Eq(t, "Weight value", proposal.Weight, 0)
+}
+
+func TestParseProposalParams(t *testing.T) {
+	proposal, err := negotiate.ParseProposal(`Text/Plain; Format="flowed"; q=0.5; ext=1`)
+	assert.FatalErr(t, "Parsing proposal", err)
+	assert.Eq(t, "Value", "text/plain", proposal.Value)
+	assert.Eq(t, "Weight value", proposal.Weight, 0.5)
+	assert.Eq(t, "Params length", 1, len(proposal.Params))
+	assert.Eq(t, "format param", "flowed", proposal.Params["format"])
+}
+
+func TestParseProposalLanguage(t *testing.T) {
+	proposal, err := negotiate.ParseProposal("en-GB;q=0.8")
+	assert.FatalErr(t, "Parsing proposal", err)
+	assert.Eq(t, "Value", "en-gb", proposal.Value)
+	assert.Eq(t, "Weight value", proposal.Weight, 0.8)
+	assert.True(t, "not a media range", !proposal.IsMediaRange())
+}
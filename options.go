@@ -0,0 +1,41 @@
+package rsvp
+
+import (
+	"maps"
+	"slices"
+)
+
+// Option configures an [Adapter] on top of the [Config] given to [NewAdapter].
+type Option func(cfg *Config)
+
+// WithCodecs appends codecs to [Config.Codecs].
+func WithCodecs(codecs ...Codec) Option {
+	return func(cfg *Config) {
+		cfg.Codecs = append(cfg.Codecs, codecs...)
+	}
+}
+
+// WithoutMediaTypes appends mediaTypes to [Config.ExcludeMediaTypes].
+func WithoutMediaTypes(mediaTypes ...string) Option {
+	return func(cfg *Config) {
+		cfg.ExcludeMediaTypes = append(cfg.ExcludeMediaTypes, mediaTypes...)
+	}
+}
+
+// WithMediaTypeQuality sets the server-side quality of mediaType in [Config.MediaTypeQuality].
+func WithMediaTypeQuality(mediaType string, quality float32) Option {
+	return func(cfg *Config) {
+		if cfg.MediaTypeQuality == nil {
+			cfg.MediaTypeQuality = make(map[string]float32)
+		}
+		cfg.MediaTypeQuality[mediaType] = quality
+	}
+}
+
+// clone copies the slices and maps of cfg so that applying an [Option] never modifies a Config shared with another [Adapter].
+func (cfg Config) clone() Config {
+	cfg.Codecs = slices.Clone(cfg.Codecs)
+	cfg.ExcludeMediaTypes = slices.Clone(cfg.ExcludeMediaTypes)
+	cfg.MediaTypeQuality = maps.Clone(cfg.MediaTypeQuality)
+	return cfg
+}
@@ -0,0 +1,226 @@
+package rsvp
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+)
+
+const (
+	SupportedMediaTypeProblemJson string = "application/problem+json"
+	SupportedMediaTypeProblemXml  string = "application/problem+xml"
+)
+
+// Problem is an error response body as specified by https://www.rfc-editor.org/rfc/rfc9457
+//
+// As [Body.Data] it is offered as application/problem+json and application/problem+xml, ahead of JSON and XML.
+// HTML and plain text are offered via [Config.ProblemTemplateName].
+//
+// If the [Body] has no status set, the response status is taken from Status.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type. When empty, it is equivalent to "about:blank".
+	Type string
+	// Title is a short, human-readable summary of the problem type.
+	Title string
+	// Status is the HTTP status code of the problem.
+	Status int
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string
+	// Instance is a URI reference that identifies this occurrence of the problem.
+	Instance string
+	// Extensions are additional members of the problem. Keys that clash with the members above are ignored.
+	Extensions map[string]any
+}
+
+// NewProblem returns a [Problem] with the given status, titled with [http.StatusText].
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func (p Problem) Error() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%d %s", p.Status, p.Title)
+	}
+	return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+}
+
+// Body returns a [Body] with this Problem as its data and its status set to Status.
+func (p Problem) Body() Body {
+	return Body{Data: p, statusCode: p.Status}
+}
+
+func (p Problem) members() map[string]any {
+	m := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	for k, v := range map[string]any{"type": p.Type, "title": p.Title, "detail": p.Detail, "instance": p.Instance} {
+		delete(m, k)
+		if v != "" {
+			m[k] = v
+		}
+	}
+	delete(m, "status")
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	return m
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.members())
+}
+
+// MarshalXML renders the problem as described by https://www.rfc-editor.org/rfc/rfc9457#appendix-B
+func (p Problem) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"}}
+	err := enc.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+
+	members := p.members()
+	for _, k := range slices.Sorted(maps.Keys(members)) {
+		err = marshalXml(enc, reflect.ValueOf(members[k]), xml.StartElement{Name: xml.Name{Local: k}}, true)
+		if err != nil {
+			return fmt.Errorf("encoding problem member %s: %w", k, err)
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// canMarshalXml reports whether every extension member of p can be rendered by [Problem.MarshalXML].
+func (p Problem) canMarshalXml() bool {
+	for k, v := range p.Extensions {
+		if !isXmlName(k) || !canMarshalXml(reflect.ValueOf(v), false) {
+			return false
+		}
+	}
+	return true
+}
+
+func asProblem(data any) (Problem, bool) {
+	switch p := data.(type) {
+	case Problem:
+		return p, true
+	case *Problem:
+		if p != nil {
+			return *p, true
+		}
+	}
+	return Problem{}, false
+}
+
+type problemJsonCodec struct{}
+
+func (problemJsonCodec) MediaType() string    { return SupportedMediaTypeProblemJson }
+func (problemJsonCodec) ContentType() string  { return "application/problem+json" }
+func (problemJsonCodec) Extensions() []string { return nil }
+
+func (problemJsonCodec) CanEncode(res *Body, cfg Config) bool {
+	_, ok := asProblem(res.Data)
+	return ok
+}
+
+func (problemJsonCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	return jsonCodec{}.Encode(w, res, cfg)
+}
+
+type problemXmlCodec struct{}
+
+func (problemXmlCodec) MediaType() string    { return SupportedMediaTypeProblemXml }
+func (problemXmlCodec) ContentType() string  { return "application/problem+xml" }
+func (problemXmlCodec) Extensions() []string { return nil }
+
+func (problemXmlCodec) CanEncode(res *Body, cfg Config) bool {
+	p, ok := asProblem(res.Data)
+	return ok && p.canMarshalXml()
+}
+
+func (problemXmlCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	return xmlCodec{}.Encode(w, res, cfg)
+}
+
+// 4xx
+
+// ProblemBadRequest returns a 400 Bad Request [Problem] as a [Body]. See [Body.StatusBadRequest].
+func ProblemBadRequest(detail string) Body {
+	return NewProblem(http.StatusBadRequest, detail).Body()
+}
+
+// ProblemUnauthorized returns a 401 Unauthorized [Problem] as a [Body]. See [Body.StatusUnauthorized].
+func ProblemUnauthorized(detail string) Body {
+	return NewProblem(http.StatusUnauthorized, detail).Body()
+}
+
+// ProblemForbidden returns a 403 Forbidden [Problem] as a [Body]. See [Body.StatusForbidden].
+func ProblemForbidden(detail string) Body {
+	return NewProblem(http.StatusForbidden, detail).Body()
+}
+
+// ProblemNotFound returns a 404 Not Found [Problem] as a [Body]. See [Body.StatusNotFound].
+func ProblemNotFound(detail string) Body {
+	return NewProblem(http.StatusNotFound, detail).Body()
+}
+
+// ProblemMethodNotAllowed returns a 405 Method Not Allowed [Problem] as a [Body]. See [Body.StatusMethodNotAllowed].
+func ProblemMethodNotAllowed(detail string) Body {
+	return NewProblem(http.StatusMethodNotAllowed, detail).Body()
+}
+
+// ProblemNotAcceptable returns a 406 Not Acceptable [Problem] as a [Body]. See [Body.StatusNotAcceptable].
+func ProblemNotAcceptable(detail string) Body {
+	return NewProblem(http.StatusNotAcceptable, detail).Body()
+}
+
+// ProblemConflict returns a 409 Conflict [Problem] as a [Body]. See [Body.StatusConflict].
+func ProblemConflict(detail string) Body {
+	return NewProblem(http.StatusConflict, detail).Body()
+}
+
+// ProblemGone returns a 410 Gone [Problem] as a [Body]. See [Body.StatusGone].
+func ProblemGone(detail string) Body {
+	return NewProblem(http.StatusGone, detail).Body()
+}
+
+// ProblemUnsupportedMediaType returns a 415 Unsupported Media Type [Problem] as a [Body]. See [Body.StatusUnsupportedMediaType].
+func ProblemUnsupportedMediaType(detail string) Body {
+	return NewProblem(http.StatusUnsupportedMediaType, detail).Body()
+}
+
+// ProblemUnprocessableEntity returns a 422 Unprocessable Entity [Problem] as a [Body]. See [Body.StatusUnprocessableEntity].
+func ProblemUnprocessableEntity(detail string) Body {
+	return NewProblem(http.StatusUnprocessableEntity, detail).Body()
+}
+
+// ProblemTooManyRequests returns a 429 Too Many Requests [Problem] as a [Body]. See [Body.StatusTooManyRequests].
+func ProblemTooManyRequests(detail string) Body {
+	return NewProblem(http.StatusTooManyRequests, detail).Body()
+}
+
+// 5xx
+
+// ProblemInternalServerError returns a 500 Internal Server Error [Problem] as a [Body]. See [Body.StatusInternalServerError].
+func ProblemInternalServerError(detail string) Body {
+	return NewProblem(http.StatusInternalServerError, detail).Body()
+}
+
+// ProblemNotImplemented returns a 501 Not Implemented [Problem] as a [Body]. See [Body.StatusNotImplemented].
+func ProblemNotImplemented(detail string) Body {
+	return NewProblem(http.StatusNotImplemented, detail).Body()
+}
+
+// ProblemServiceUnavailable returns a 503 Service Unavailable [Problem] as a [Body]. See [Body.StatusServiceUnavailable].
+func ProblemServiceUnavailable(detail string) Body {
+	return NewProblem(http.StatusServiceUnavailable, detail).Body()
+}
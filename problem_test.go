@@ -0,0 +1,149 @@
+package rsvp_test
+
+import (
+	html "html/template"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+func TestProblemMediaTypes(t *testing.T) {
+	cfg := rsvp.Config{}
+	res := rsvp.ProblemNotFound("no such user")
+	actual := slices.Collect(res.MediaTypes(cfg))
+
+	expected := []string{
+		rsvp.SupportedMediaTypeProblemJson,
+		rsvp.SupportedMediaTypeProblemXml,
+		rsvp.SupportedMediaTypeJson,
+		rsvp.SupportedMediaTypeXml,
+	}
+
+	assert.SlicesEq(t, "media types", expected, actual)
+}
+
+func TestProblemJson(t *testing.T) {
+	res := rsvp.ProblemNotFound("no such user")
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusNotFound, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/problem+json", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "body contents", `{"detail":"no such user","status":404,"title":"Not Found"}`+"\n", rec.Body.String())
+}
+
+func TestProblemJsonExtensions(t *testing.T) {
+	problem := rsvp.Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "You do not have enough credit.",
+		Status:     http.StatusForbidden,
+		Detail:     "Your current balance is 30, but that costs 50.",
+		Instance:   "/account/12345/msgs/abc",
+		Extensions: map[string]any{"balance": 30, "status": "ignored"},
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data(problem), rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code taken from problem", http.StatusForbidden, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/json", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "body contents", `{"balance":30,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc","status":403,"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}`+"\n", rec.Body.String())
+}
+
+func TestProblemXml(t *testing.T) {
+	res := rsvp.ProblemConflict("already exists")
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/problem+xml")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusConflict, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/problem+xml", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "body contents", `<problem xmlns="urn:ietf:rfc:7807"><detail>already exists</detail><status>409</status><title>Conflict</title></problem>`, rec.Body.String())
+}
+
+func TestProblemXmlNestedExtensions(t *testing.T) {
+	problem := rsvp.NewProblem(http.StatusUnprocessableEntity, "x")
+	problem.Extensions = map[string]any{
+		"errors":  []string{"a", "b"},
+		"context": map[string]any{"field": "email"},
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/problem+xml")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(problem.Body(), rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "Content type", "application/problem+xml", rec.Header().Get("Content-Type"))
+	assert.Eq(t, "body contents", `<problem xmlns="urn:ietf:rfc:7807"><context><field>email</field></context><detail>x</detail><errors><item>a</item><item>b</item></errors><status>422</status><title>Unprocessable Entity</title></problem>`, rec.Body.String())
+}
+
+func TestProblemXmlIsWithdrawnWhenExtensionsAreUnrepresentable(t *testing.T) {
+	cases := map[string]map[string]any{
+		"non-string keys":  {"counts": map[int]int{1: 2}},
+		"invalid key name": {"1st": "a"},
+	}
+
+	for name, extensions := range cases {
+		t.Run(name, func(t *testing.T) {
+			problem := rsvp.NewProblem(http.StatusBadRequest, "")
+			problem.Extensions = extensions
+			res := problem.Body()
+			actual := slices.Collect(res.MediaTypes(rsvp.Config{}))
+			assert.SlicesEq(t, "media types", []string{rsvp.SupportedMediaTypeProblemJson, rsvp.SupportedMediaTypeJson}, actual)
+		})
+	}
+}
+
+func TestProblemTemplate(t *testing.T) {
+	cfg := rsvp.Config{
+		HtmlTemplate:        html.Must(html.New("problem").Parse(`<h1>{{.Title}}</h1><p>{{.Detail}}</p>`)),
+		ProblemTemplateName: "problem",
+	}
+
+	handler := rsvp.NewAdapter(cfg).AdaptFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		w.DefaultTemplateName("user")
+		return rsvp.ProblemNotFound("no such user")
+	})
+
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusNotFound, resp.StatusCode)
+	assert.Eq(t, "Content type", "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "body contents", `<h1>Not Found</h1><p>no such user</p>`, rec.Body.String())
+}
+
+func TestProblemBodyStatusOverridesProblemStatus(t *testing.T) {
+	res := rsvp.Data(rsvp.NewProblem(http.StatusBadRequest, "")).StatusConflict()
+	req := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+	assert.Eq(t, "Status code", http.StatusConflict, rec.Code)
+}
+
+func TestProblemError(t *testing.T) {
+	assert.Eq(t, "error with detail", "404 Not Found: gone fishing", rsvp.NewProblem(404, "gone fishing").Error())
+	assert.Eq(t, "error without detail", "500 Internal Server Error", rsvp.NewProblem(500, "").Error())
+}
@@ -3,7 +3,7 @@
 //
 // The middleware negotiates response format based on the Accept
 // header, supporting JSON, XML, HTML, plain text, CSV, binary,
-// and MessagePack (using -tags=rsvp_msgpack).
+// and MessagePack (using -tags=rsvp_msgpack and [WithMsgpack]).
 // This content negotiation extends to ALL responses, including redirects,
 // allowing you to provide rich feedback in many contexts.
 //
@@ -16,6 +16,12 @@
 // as REST and progressive enhancement.
 package rsvp
 
+import (
+	"context"
+	"maps"
+	"net/http"
+)
+
 // Body represents the content body of an HTTP response.
 //
 // By default, it represents a 200 OK response. The Body.Status* methods (e.g. [Body.StatusFound]) may be used to set a non-200 status.
@@ -24,6 +30,9 @@ type Body struct {
 	//
 	// IMPORTANT: A nil Data renders as JSON "null\n", not an empty response.
 	// Use Data("") for a blank text/plain response body, or [Blank] for a blank response with no Content-Type.
+	//
+	// Data may be an [iter.Seq], an [iter.Seq2] whose second value is an error, or a channel, to render its items as they are yielded rather than all at once. It is streamed as a JSON array, as XML elements, as CSV rows if its items are structs, and to templates that range over it.
+	// Rendering stops if the request's context is done, or an error is yielded, which is passed to [Config.OnError].
 	Data any
 	// TemplateName sets the template that this Body may attempt to select from
 	// [Config.HtmlTemplate] or [Config.TextTemplate],
@@ -41,6 +50,47 @@ type Body struct {
 	blankBodyOverride bool
 
 	redirectLocation string
+
+	mediaTypeQuality map[string]float32
+
+	// mediaTypeParams are the parameters of the negotiated variant of the media type, e.g. header=absent for text/csv.
+	mediaTypeParams map[string]string
+
+	csvOpts *CsvOptions
+
+	xmlRootName string
+	xmlOpts     *XmlOptions
+
+	// keepStatus stops negotiation from replacing the status with 404 or 406.
+	keepStatus bool
+
+	// ctx is the context of the request that is being rendered. See [Body.Context].
+	ctx context.Context
+}
+
+// Context returns the context of the request that res is being rendered for, or [context.Background] if it is not being rendered.
+//
+// A [Codec] that renders [Body.Data] gradually, such as a stream, should stop once the context is done.
+func (res *Body) Context() context.Context {
+	if res.ctx == nil {
+		return context.Background()
+	}
+	return res.ctx
+}
+
+// status is the status code that this Body will be written with.
+func (res *Body) status() int {
+	if res.statusCode != 0 {
+		return res.statusCode
+	}
+	if p, ok := asProblem(res.Data); ok && p.Status != 0 {
+		return p.Status
+	}
+	return http.StatusOK
+}
+
+func (res *Body) isRedirect() bool {
+	return 300 <= res.statusCode && res.statusCode < 400
 }
 
 func (res *Body) isBlank() bool {
@@ -61,3 +111,25 @@ func Blank() Body {
 func Data(data any) Body {
 	return Body{Data: data}
 }
+
+// MediaTypeQuality sets the server-side quality (between 0 and 1) of mediaType for this Body only, taking precedence over [Config.MediaTypeQuality].
+//
+// e.g. rsvp.Data(report).MediaTypeQuality(rsvp.SupportedMediaTypeXml, 0.1) for an endpoint where XML is lossy.
+func (res Body) MediaTypeQuality(mediaType string, quality float32) Body {
+	res.mediaTypeQuality = maps.Clone(res.mediaTypeQuality)
+	if res.mediaTypeQuality == nil {
+		res.mediaTypeQuality = make(map[string]float32)
+	}
+	res.mediaTypeQuality[mediaType] = quality
+	return res
+}
+
+func (res *Body) quality(mediaType string, cfg Config) float32 {
+	if q, ok := res.mediaTypeQuality[mediaType]; ok {
+		return q
+	}
+	if q, ok := cfg.MediaTypeQuality[mediaType]; ok {
+		return q
+	}
+	return 1
+}
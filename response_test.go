@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	text "text/template"
 
@@ -970,7 +971,7 @@ func TestRequestXmlEmptyString(t *testing.T) {
 	assert.Eq(t, "Status code", 200, statusCode)
 	assert.Eq(t, "Content type", "application/xml", resp.Header.Get("Content-Type"))
 	body := rec.Body.String()
-	assert.Eq(t, "body contents", "<string></string>", body)
+	assert.Eq(t, "body contents", "<data></data>", body)
 }
 
 func TestRequestXmlNull(t *testing.T) {
@@ -987,7 +988,7 @@ func TestRequestXmlNull(t *testing.T) {
 	assert.Eq(t, "Status code", 200, statusCode)
 	assert.Eq(t, "Content type", "application/xml", resp.Header.Get("Content-Type"))
 	body := rec.Body.String()
-	assert.Eq(t, "body contents", "", body)
+	assert.Eq(t, "body contents", "<data></data>", body)
 }
 
 func TestRespondXmlEmptyString(t *testing.T) {
@@ -1004,7 +1005,7 @@ func TestRespondXmlEmptyString(t *testing.T) {
 	assert.Eq(t, "Status code", 200, statusCode)
 	assert.Eq(t, "Content type", "application/xml", resp.Header.Get("Content-Type"))
 	body := rec.Body.String()
-	assert.Eq(t, "body contents", "<string></string>", body)
+	assert.Eq(t, "body contents", "<data></data>", body)
 }
 
 func TestRespondXmlNull(t *testing.T) {
@@ -1021,7 +1022,7 @@ func TestRespondXmlNull(t *testing.T) {
 	assert.Eq(t, "Status code", 200, statusCode)
 	assert.Eq(t, "Content type", "application/xml", resp.Header.Get("Content-Type"))
 	body := rec.Body.String()
-	assert.Eq(t, "body contents", "", body)
+	assert.Eq(t, "body contents", "<data></data>", body)
 }
 
 func TestRequestForXmlButServingJson(t *testing.T) {
@@ -1071,3 +1072,235 @@ func TestRequestCsv(t *testing.T) {
 	body := rec.Body.String()
 	assert.Eq(t, "body contents", "status,number\nOK,3\n", body)
 }
+
+// unflushedCsvResource leaves its rows buffered in the csv.Writer, which must be flushed after MarshalCsv.
+type unflushedCsvResource struct{}
+
+func (unflushedCsvResource) MarshalCsv(w *csv.Writer) error {
+	return w.Write([]string{"status", "number"})
+}
+
+func TestRequestCsvIsFlushed(t *testing.T) {
+	res := rsvp.Body{Data: unflushedCsvResource{}}
+	req := httptest.NewRequest("GET", "/resource.csv", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "body contents", "status,number\n", rec.Body.String())
+}
+
+func TestZeroWeightIsNotAcceptable(t *testing.T) {
+	res := rsvp.Body{Data: "Hello"}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/plain;q=0, */*;q=0.1")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", 200, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/json", resp.Header.Get("Content-Type"))
+}
+
+func TestOnlyZeroWeightIsNotAcceptable(t *testing.T) {
+	res := rsvp.Body{Data: map[string]string{"hello": "world"}}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json;q=0")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusNotAcceptable, resp.StatusCode)
+}
+
+func TestAcceptJsonWithCharset(t *testing.T) {
+	res := rsvp.Body{Data: map[string]string{"hello": "world"}}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/json; charset=utf-8")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusOK, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/json", resp.Header.Get("Content-Type"))
+}
+
+func TestAcceptCsvWithCharset(t *testing.T) {
+	res := rsvp.Body{Data: CsvResource{Status: "OK", Number: 3}}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/csv;charset=utf-8")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusOK, resp.StatusCode)
+	assert.Eq(t, "Content type", "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+}
+
+func TestAcceptCsvWithUnofferedCharset(t *testing.T) {
+	res := rsvp.Body{Data: CsvResource{Status: "OK", Number: 3}}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/csv;charset=iso-8859-1")
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusNotAcceptable, resp.StatusCode)
+}
+
+func TestConfigMediaTypeQualityPrefersHtml(t *testing.T) {
+	res := rsvp.Body{Data: "Hello", TemplateName: "tm"}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "*/*")
+	rec := httptest.NewRecorder()
+
+	cfg := rsvp.Config{
+		HtmlTemplate: html.Must(html.New("tm").Parse(`<p>{{.}}</p>`)),
+		MediaTypeQuality: map[string]float32{
+			rsvp.SupportedMediaTypePlaintext: 0.5,
+			rsvp.SupportedMediaTypeJson:      0.5,
+			rsvp.SupportedMediaTypeXml:       0.5,
+		},
+	}
+	err := makeHandler(res, cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", 200, resp.StatusCode)
+	assert.Eq(t, "Content type", "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "body contents", "<p>Hello</p>", rec.Body.String())
+}
+
+func TestBodyMediaTypeQualityDemotesXml(t *testing.T) {
+	res := rsvp.Data(fixtures.RssItem{Title: "Lossy"}).MediaTypeQuality(rsvp.SupportedMediaTypeXml, 0.1)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/xml, application/json;q=0.5")
+	rec := httptest.NewRecorder()
+
+	cfg := rsvp.Config{
+		MediaTypeQuality: map[string]float32{rsvp.SupportedMediaTypeXml: 1},
+	}
+	err := makeHandler(res, cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", 200, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/json", resp.Header.Get("Content-Type"))
+}
+
+func TestZeroMediaTypeQualityIsOnlyChosenByExtension(t *testing.T) {
+	cfg := rsvp.Config{
+		MediaTypeQuality: map[string]float32{rsvp.SupportedMediaTypeXml: 0},
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+	err := makeHandler(rsvp.Data("Hello"), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+	assert.Eq(t, "Status code by Accept", http.StatusNotAcceptable, rec.Code)
+
+	req = httptest.NewRequest("GET", "/hello.xml", nil)
+	rec = httptest.NewRecorder()
+	err = makeHandler(rsvp.Data("Hello"), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+	assert.Eq(t, "Status code by extension", http.StatusOK, rec.Code)
+	assert.Eq(t, "Content type by extension", "application/xml", rec.Result().Header.Get("Content-Type"))
+}
+
+func TestBufferedSetsContentLength(t *testing.T) {
+	res := rsvp.Data("Hello")
+	req := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, rsvp.Config{Buffered: true})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", 200, resp.StatusCode)
+	assert.Eq(t, "Content length", "5", resp.Header.Get("Content-Length"))
+	assert.Eq(t, "body contents", "Hello", rec.Body.String())
+}
+
+func TestVaryAcceptIsNotRepeated(t *testing.T) {
+	cases := map[string]string{
+		"listed with others": "Accept-Encoding, accept",
+		"wildcard":           "*",
+	}
+
+	for name, vary := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Vary", vary)
+
+			err := makeHandler(rsvp.Data("Hello"), rsvp.Config{})(rec, req)
+			assert.FatalErr(t, "handler", err)
+
+			assert.SlicesEq(t, "Vary header", []string{vary}, rec.Result().Header.Values("Vary"))
+		})
+	}
+}
+
+func TestBufferedHtmlTemplateErrorBecomes500(t *testing.T) {
+	res := rsvp.Body{Data: "Hello", TemplateName: "tm"}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+
+	cfg := rsvp.Config{Buffered: true}
+	cfg.HtmlTemplate = html.Must(html.New("tm").Parse(`<div>{{.NonExistent}}</div>`))
+
+	err := makeHandler(res, cfg)(rec, req)
+	assert.True(t, "template error is returned", err != nil)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusInternalServerError, resp.StatusCode)
+	assert.Eq(t, "Content type", "application/problem+json", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "Vary header", "Accept", strings.Join(resp.Header.Values("Vary"), ", "))
+	assert.Eq(t, "body contents", `{"status":500,"title":"Internal Server Error"}`+"\n", rec.Body.String())
+}
+
+func TestBufferedHtmlTemplateErrorUsesProblemTemplate(t *testing.T) {
+	res := rsvp.Body{Data: "Hello", TemplateName: "tm"}
+	req := httptest.NewRequest("GET", "/page.html", nil)
+	rec := httptest.NewRecorder()
+
+	cfg := rsvp.Config{Buffered: true, ProblemTemplateName: "problem"}
+	cfg.HtmlTemplate = html.Must(html.New("tm").Parse(`<div>{{.NonExistent}}</div>`))
+	cfg.HtmlTemplate = html.Must(cfg.HtmlTemplate.New("problem").Parse(`<h1>{{.Title}}</h1>`))
+
+	_ = makeHandler(res, cfg)(rec, req)
+
+	resp := rec.Result()
+	assert.Eq(t, "Status code", http.StatusInternalServerError, resp.StatusCode)
+	assert.Eq(t, "Content type", "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Eq(t, "Content length", "30", resp.Header.Get("Content-Length"))
+	assert.Eq(t, "body contents", `<h1>Internal Server Error</h1>`, rec.Body.String())
+}
+
+func TestBufferedFailingFallbackWritesPlain500(t *testing.T) {
+	res := rsvp.Body{Data: "Hello", TemplateName: "tm"}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+
+	cfg := rsvp.Config{Buffered: true, ProblemTemplateName: "tm"}
+	cfg.HtmlTemplate = html.Must(html.New("tm").Parse(`<div>{{.NonExistent}}</div>`))
+
+	_ = makeHandler(res, cfg)(rec, req)
+
+	assert.Eq(t, "Status code", http.StatusInternalServerError, rec.Code)
+	assert.Eq(t, "body contents", "Internal Server Error\n", rec.Body.String())
+}
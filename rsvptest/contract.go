@@ -0,0 +1,104 @@
+package rsvptest
+
+import (
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+)
+
+// ContractOption configures [Contract].
+type ContractOption func(c *contract)
+
+type contract struct {
+	snapshots       bool
+	snapshotHeaders []string
+}
+
+// WithSnapshots makes [Contract] compare the response of each format with a snapshot file, as described by [Snapshot].
+//
+// headers are the headers to include in each snapshot, in addition to Content-Type.
+func WithSnapshots(headers ...string) ContractOption {
+	return func(c *contract) {
+		c.snapshots = true
+		c.snapshotHeaders = headers
+	}
+}
+
+// Contract calls handler with r, and then renders the [rsvp.Body] it returns as every media type that the Body offers (see [rsvp.Body.MediaTypes]).
+//
+// Each media type is rendered by a separate subtest, named after the media type with "/" replaced by "_" (e.g. "application_json") so that it can be selected with -run, which calls handler again with r and an Accept header of only that media type.
+// A subtest fails if rendering fails, or if a different media type is negotiated.
+//
+// This catches formats that are advertised but fail on real data, such as a template that is found by Lookup but fails to execute.
+//
+// The recorded responses are returned by media type.
+func Contract(t *testing.T, cfg rsvp.Config, handler rsvp.Handler, r *http.Request, opts ...ContractOption) map[string]*httptest.ResponseRecorder {
+	t.Helper()
+
+	var c contract
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	dir := filepath.Join("testdata", t.Name())
+	responses := make(map[string]*httptest.ResponseRecorder)
+	for _, mediaType := range offeredMediaTypes(cfg, handler, r) {
+		t.Run(strings.ReplaceAll(mediaType, "/", "_"), func(t *testing.T) {
+			t.Helper()
+
+			req := r.Clone(r.Context())
+			req.Header.Set("Accept", mediaType)
+
+			rec, err := Do(cfg, handler, req)
+			responses[mediaType] = rec
+			if err != nil {
+				t.Fatalf("rendering %s failed: %s", mediaType, err)
+			}
+
+			got, _, _ := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+			if got != mediaType {
+				t.Errorf("negotiated %q instead of %q", got, mediaType)
+			}
+
+			if c.snapshots {
+				snapshot(t, snapshotPath(dir, rec), formatResponse(rec, c.snapshotHeaders))
+			}
+		})
+	}
+
+	return responses
+}
+
+// offeredMediaTypes returns the media types that handler's Body offers for r.
+func offeredMediaTypes(cfg rsvp.Config, handler rsvp.Handler, r *http.Request) []string {
+	rec, body := Serve(handler, r.Clone(r.Context()))
+	if contentType := rec.Header().Get("Content-Type"); contentType != "" {
+		// The handler has decided the media type itself
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		return []string{mediaType}
+	}
+
+	body.TemplateName = TemplateName(rec, body)
+	req := r.Clone(r.Context())
+	req.Header.Del("Accept")
+	x := rsvp.Explain(body, cfg, req)
+	if x.Ext != "" {
+		// The extension of the path decides the media type
+		return []string{x.MediaType}
+	}
+
+	// More than one codec may offer the same media type, e.g. text/plain for both a string and a text template
+	mediaTypes := make([]string, 0, len(x.Supported))
+	for _, mediaType := range x.Supported {
+		if !slices.Contains(mediaTypes, mediaType) {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	return mediaTypes
+}
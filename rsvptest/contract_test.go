@@ -0,0 +1,92 @@
+package rsvptest_test
+
+import (
+	"encoding/csv"
+	html "html/template"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	text "text/template"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+	"github.com/Teajey/rsvp/rsvptest"
+)
+
+type user struct {
+	Name string `json:"name" xml:"name"`
+}
+
+type userList []user
+
+func (ul userList) MarshalCsv(w *csv.Writer) error {
+	for _, u := range ul {
+		err := w.Write([]string{u.Name})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listUsers(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+	w.DefaultTemplateName("users")
+	return rsvp.Data(userList{{Name: "Alice"}, {Name: "Bob"}})
+}
+
+func TestContract(t *testing.T) {
+	cfg := rsvp.Config{
+		HtmlTemplate: html.Must(html.New("users").Parse(`<ul>{{range .}}<li>{{.Name}}</li>{{end}}</ul>`)),
+	}
+	req := httptest.NewRequest("GET", "/users", nil)
+
+	responses := rsvptest.Contract(t, cfg, rsvp.HandlerFunc(listUsers), req, rsvptest.WithSnapshots())
+
+	mediaTypes := make([]string, 0, len(responses))
+	for mediaType := range responses {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	slices.Sort(mediaTypes)
+	assert.Eq(t, "media types", "application/json application/json-seq application/jsonl application/x-ndjson application/xml text/csv text/html text/tab-separated-values", strings.Join(mediaTypes, " "))
+	assert.Eq(t, "html", "<ul><li>Alice</li><li>Bob</li></ul>", responses["text/html"].Body.String())
+}
+
+func TestContractDedupesMediaTypes(t *testing.T) {
+	cfg := rsvp.Config{
+		TextTemplate: text.Must(text.New("greeting").Parse(`Hello, {{.}}!`)),
+	}
+	handler := rsvp.HandlerFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		w.DefaultTemplateName("greeting")
+		return rsvp.Data("Alice")
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+
+	responses := rsvptest.Contract(t, cfg, handler, req)
+
+	assert.Eq(t, "responses", 3, len(responses))
+	assert.Eq(t, "text", "Hello, Alice!", responses["text/plain"].Body.String())
+}
+
+func TestContractExtension(t *testing.T) {
+	req := httptest.NewRequest("GET", "/users.csv", nil)
+
+	responses := rsvptest.Contract(t, rsvp.Config{}, rsvp.HandlerFunc(listUsers), req)
+
+	assert.Eq(t, "responses", 1, len(responses))
+	assert.Eq(t, "csv", "Alice\nBob\n", responses["text/csv"].Body.String())
+}
+
+func TestContractPredeterminedContentType(t *testing.T) {
+	handler := rsvp.HandlerFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		w.Header().Set("Content-Type", "application/json")
+		return rsvp.Data("Hello")
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+
+	responses := rsvptest.Contract(t, rsvp.Config{}, handler, req)
+
+	assert.Eq(t, "responses", 1, len(responses))
+	assert.Eq(t, "json", "\"Hello\"\n", responses["application/json"].Body.String())
+}
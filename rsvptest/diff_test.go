@@ -0,0 +1,24 @@
+package rsvptest
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	expected := "200 OK\nContent-Type: application/json\n\n{\"name\":\"Alice\"}\n"
+	actual := "200 OK\nContent-Type: application/json\n\n{\"name\":\"Bob\"}\n"
+
+	got := diff(expected, actual)
+
+	want := " 200 OK\n Content-Type: application/json\n \n-{\"name\":\"Alice\"}\n+{\"name\":\"Bob\"}\n"
+	if got != want {
+		t.Errorf("diff:\n%s\n!=\n%s", got, want)
+	}
+}
+
+func TestDiffMissingNewline(t *testing.T) {
+	got := diff("a\nb\n", "a\nb")
+
+	want := " a\n-b\n+b\n\\ No newline at end of file\n"
+	if got != want {
+		t.Errorf("diff:\n%q\n!=\n%q", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+// Package rsvptest provides utilities for testing rsvp handlers, in the spirit of [net/http/httptest].
+//
+// A handler may be tested without HTTP by calling it with a [Recorder] and inspecting the [rsvp.Body] it returns:
+//
+//	rec, body := rsvptest.Serve(rsvp.HandlerFunc(getUser), httptest.NewRequest("GET", "/users/1", nil))
+//	if rsvptest.Status(body) != http.StatusOK { ... }
+//
+// Or it may be run through the full negotiation and rendering pipeline with [Do], [WithAccept] or [WithExtension].
+package rsvptest
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/inspect"
+)
+
+// Recorder is an [rsvp.ResponseWriter] that records what a handler does with it.
+type Recorder struct {
+	// HeaderMap contains the headers set by the handler.
+	HeaderMap http.Header
+	// DefaultTemplate is the name most recently passed to [Recorder.DefaultTemplateName].
+	DefaultTemplate string
+}
+
+// NewRecorder returns an initialized [Recorder].
+func NewRecorder() *Recorder {
+	return &Recorder{
+		HeaderMap: make(http.Header),
+	}
+}
+
+// Header implements [rsvp.ResponseWriter].
+func (rec *Recorder) Header() http.Header {
+	if rec.HeaderMap == nil {
+		rec.HeaderMap = make(http.Header)
+	}
+	return rec.HeaderMap
+}
+
+// DefaultTemplateName implements [rsvp.ResponseWriter].
+func (rec *Recorder) DefaultTemplateName(name string) {
+	rec.DefaultTemplate = name
+}
+
+// Serve calls handler with a new [Recorder] and r, returning the recorder and the [rsvp.Body] that handler returned.
+//
+// Nothing is negotiated or rendered.
+func Serve(handler rsvp.Handler, r *http.Request) (*Recorder, rsvp.Body) {
+	rec := NewRecorder()
+	body := handler.ServeHTTP(rec, r)
+	return rec, body
+}
+
+// Status returns the status code that body will be written with, before content negotiation has a chance to replace it with 404 or 406.
+func Status(body rsvp.Body) int {
+	return inspect.Status(body)
+}
+
+// Location returns the redirect location of body, which is empty unless it was set by one of the redirecting Body.Status* methods, e.g. [rsvp.Body.StatusSeeOther].
+func Location(body rsvp.Body) string {
+	return inspect.Location(body)
+}
+
+// TemplateName returns the name of the template that body will be rendered with, which is [rsvp.Body.TemplateName], or otherwise the default template name recorded by rec.
+func TemplateName(rec *Recorder, body rsvp.Body) string {
+	if body.TemplateName != "" {
+		return body.TemplateName
+	}
+	return rec.DefaultTemplate
+}
+
+// Do runs handler through the full content negotiation and rendering pipeline of [rsvp.Write] for r.
+//
+// The recorded response is returned along with any error that [rsvp.Write] returned.
+func Do(cfg rsvp.Config, handler rsvp.Handler, r *http.Request) (*httptest.ResponseRecorder, error) {
+	rec := httptest.NewRecorder()
+	err := rsvp.Write(rec, r, cfg, handler)
+	return rec, err
+}
+
+// WithAccept is like [Do], with a GET request to "/" that has the given Accept header.
+func WithAccept(cfg rsvp.Config, handler rsvp.Handler, accept string) (*httptest.ResponseRecorder, error) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	return Do(cfg, handler, r)
+}
+
+// WithExtension is like [Do], with a GET request to "/index." followed by ext, e.g. "/index.json".
+func WithExtension(cfg rsvp.Config, handler rsvp.Handler, ext string) (*httptest.ResponseRecorder, error) {
+	r := httptest.NewRequest(http.MethodGet, "/index."+ext, nil)
+	return Do(cfg, handler, r)
+}
@@ -0,0 +1,81 @@
+package rsvptest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+	"github.com/Teajey/rsvp/rsvptest"
+)
+
+func createUser(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+	w.DefaultTemplateName("user.gotmpl")
+	w.Header().Set("X-User-Id", "123")
+	if r.FormValue("name") == "" {
+		return rsvp.ProblemUnprocessableEntity("name is required")
+	}
+	return rsvp.Data("created").StatusSeeOther("/users/123")
+}
+
+func TestServe(t *testing.T) {
+	req := httptest.NewRequest("POST", "/users?name=Alice", nil)
+
+	rec, body := rsvptest.Serve(rsvp.HandlerFunc(createUser), req)
+
+	assert.Eq(t, "header", "123", rec.Header().Get("X-User-Id"))
+	assert.Eq(t, "status", http.StatusSeeOther, rsvptest.Status(body))
+	assert.Eq(t, "location", "/users/123", rsvptest.Location(body))
+	assert.Eq(t, "template name", "user.gotmpl", rsvptest.TemplateName(rec, body))
+}
+
+func TestServeProblem(t *testing.T) {
+	req := httptest.NewRequest("POST", "/users", nil)
+
+	_, body := rsvptest.Serve(rsvp.HandlerFunc(createUser), req)
+
+	assert.Eq(t, "status", http.StatusUnprocessableEntity, rsvptest.Status(body))
+	assert.Eq(t, "location", "", rsvptest.Location(body))
+}
+
+func TestStatusDefault(t *testing.T) {
+	assert.Eq(t, "status", http.StatusOK, rsvptest.Status(rsvp.Data("Hello")))
+}
+
+func TestTemplateNameOverride(t *testing.T) {
+	rec := rsvptest.NewRecorder()
+	rec.DefaultTemplateName("default")
+
+	assert.Eq(t, "template name", "specific", rsvptest.TemplateName(rec, rsvp.Body{TemplateName: "specific"}))
+}
+
+func TestWithAccept(t *testing.T) {
+	handler := rsvp.HandlerFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data([]int{1, 2})
+	})
+
+	rec, err := rsvptest.WithAccept(rsvp.Config{}, handler, "application/xml, application/json;q=0.9")
+	assert.FatalErr(t, "WithAccept", err)
+
+	assert.Eq(t, "status", http.StatusOK, rec.Code)
+	assert.Eq(t, "content type", "application/xml", rec.Header().Get("Content-Type"))
+}
+
+func TestWithExtension(t *testing.T) {
+	handler := rsvp.HandlerFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data([]int{1, 2})
+	})
+
+	rec, err := rsvptest.WithExtension(rsvp.Config{}, handler, "json")
+	assert.FatalErr(t, "WithExtension", err)
+
+	assert.Eq(t, "status", http.StatusOK, rec.Code)
+	assert.Eq(t, "content type", "application/json", rec.Header().Get("Content-Type"))
+	assert.Eq(t, "body", "[1,2]\n", rec.Body.String())
+
+	rec, err = rsvptest.WithExtension(rsvp.Config{}, handler, "csv")
+	assert.FatalErr(t, "WithExtension", err)
+
+	assert.Eq(t, "status", http.StatusNotFound, rec.Code)
+}
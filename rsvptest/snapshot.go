@@ -0,0 +1,140 @@
+package rsvptest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Snapshot compares the response recorded by rec with a snapshot file, failing t with a line diff if they differ.
+//
+// The snapshot holds the status, the Content-Type header and any other headers named by headers, and the body.
+// It is stored in testdata/<test name>/, named after the media type of the response, e.g. testdata/TestGetUser/application_json.snap, so that a test may snapshot one response per negotiated format.
+//
+// Missing snapshot files are created, and fail the test. Set the UPDATE_SNAPSHOTS environment variable to overwrite snapshots that do not match.
+func Snapshot(t *testing.T, rec *httptest.ResponseRecorder, headers ...string) {
+	t.Helper()
+	snapshot(t, snapshotPath(filepath.Join("testdata", t.Name()), rec), formatResponse(rec, headers))
+}
+
+func snapshotPath(dir string, rec *httptest.ResponseRecorder) string {
+	name := "blank"
+	if mediaType, _, err := mime.ParseMediaType(rec.Header().Get("Content-Type")); err == nil {
+		name = strings.ReplaceAll(mediaType, "/", "_")
+	}
+	return filepath.Join(dir, name+".snap")
+}
+
+// formatResponse renders rec similarly to an HTTP/1.1 response, without the protocol version.
+func formatResponse(rec *httptest.ResponseRecorder, headers []string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d %s\n", rec.Code, http.StatusText(rec.Code))
+
+	names := append([]string{"Content-Type"}, headers...)
+	for i, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if i > 0 && name == "Content-Type" {
+			continue
+		}
+		for _, v := range rec.Header().Values(name) {
+			fmt.Fprintf(&b, "%s: %s\n", name, v)
+		}
+	}
+
+	b.WriteString("\n")
+	b.Write(rec.Body.Bytes())
+	return b.Bytes()
+}
+
+func writeSnapshot(t *testing.T, path string, actual []byte) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatalf("Failed to create snapshot directory: %s", err)
+	}
+	err = os.WriteFile(path, actual, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write snapshot file: %s", err)
+	}
+}
+
+func snapshot(t *testing.T, path string, actual []byte) {
+	t.Helper()
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		writeSnapshot(t, path, actual)
+		t.Errorf("Snapshot file created: %s", path)
+		return
+	}
+	if err != nil {
+		t.Fatalf("Failed to read snapshot file: %s", err)
+	}
+
+	if bytes.Equal(expected, actual) {
+		return
+	}
+
+	if os.Getenv("UPDATE_SNAPSHOTS") != "" {
+		writeSnapshot(t, path, actual)
+		t.Logf("Snapshot file %s updated", path)
+		return
+	}
+
+	t.Errorf("Response doesn't match snapshot %s (-snapshot +actual):\n%s", path, diff(string(expected), string(actual)))
+}
+
+// diff returns a line diff of a and b, with lines only in a prefixed by "-", lines only in b prefixed by "+", and common lines prefixed by " ".
+func diff(a, b string) string {
+	as := strings.SplitAfter(a, "\n")
+	bs := strings.SplitAfter(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of as[i:] and bs[j:]
+	lcs := make([][]int, len(as)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bs)+1)
+	}
+	for i := len(as) - 1; i >= 0; i-- {
+		for j := len(bs) - 1; j >= 0; j-- {
+			if as[i] == bs[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	line := func(prefix string, s string) {
+		if s == "" {
+			return
+		}
+		out.WriteString(prefix)
+		out.WriteString(s)
+		if !strings.HasSuffix(s, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+	i, j := 0, 0
+	for i < len(as) || j < len(bs) {
+		switch {
+		case i < len(as) && j < len(bs) && as[i] == bs[j]:
+			line(" ", as[i])
+			i++
+			j++
+		case i < len(as) && (j == len(bs) || lcs[i+1][j] >= lcs[i][j+1]):
+			line("-", as[i])
+			i++
+		default:
+			line("+", bs[j])
+			j++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,22 @@
+package rsvptest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+	"github.com/Teajey/rsvp/rsvptest"
+)
+
+func TestSnapshot(t *testing.T) {
+	handler := rsvp.HandlerFunc(func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(user{Name: "Alice"}).StatusSeeOther("/users/alice")
+	})
+
+	for _, accept := range []string{"application/json", "application/xml"} {
+		rec, err := rsvptest.WithAccept(rsvp.Config{}, handler, accept)
+		assert.FatalErr(t, "WithAccept", err)
+		rsvptest.Snapshot(t, rec, "Location")
+	}
+}
@@ -172,6 +172,15 @@ func (r Body) StatusGone() Body {
 	return r
 }
 
+// StatusUnsupportedMediaType sets the response as 415 Unsupported Media Type.
+//
+// It indicates that the server refuses to accept the request because the
+// content is in a format that it does not support. See [Decode].
+func (r Body) StatusUnsupportedMediaType() Body {
+	r.statusCode = http.StatusUnsupportedMediaType
+	return r
+}
+
 // StatusUnprocessableEntity sets the response as 422 Unprocessable Entity.
 //
 // It indicates that the request was well-formed but was unable to be followed
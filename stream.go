@@ -0,0 +1,237 @@
+package rsvp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// streamFlushInterval is how often a stream that is being rendered is flushed to the client.
+const streamFlushInterval = 100 * time.Millisecond
+
+// stream is a [Body.Data] that yields its items one at a time: an [iter.Seq], an [iter.Seq2] whose second value is an error, or a channel that may be received from.
+type stream struct {
+	v    reflect.Value
+	elem reflect.Type
+	// withErr is set if v is an iter.Seq2 whose second value is an error.
+	withErr bool
+}
+
+var errorType = reflect.TypeFor[error]()
+
+func asStream(data any) (stream, bool) {
+	v := reflect.ValueOf(data)
+	if !v.IsValid() {
+		return stream{}, false
+	}
+	switch v.Kind() {
+	case reflect.Func:
+		if v.IsNil() {
+			return stream{}, false
+		}
+		if elem, ok := seqElem(v.Type()); ok {
+			return stream{v: v, elem: elem}, true
+		}
+		if elem, ok := seq2ErrElem(v.Type()); ok {
+			return stream{v: v, elem: elem, withErr: true}, true
+		}
+	case reflect.Chan:
+		if !v.IsNil() && v.Type().ChanDir()&reflect.RecvDir != 0 {
+			return stream{v: v, elem: v.Type().Elem()}, true
+		}
+	}
+	return stream{}, false
+}
+
+// seqElem returns T if t is the type of an iter.Seq[T].
+func seqElem(t reflect.Type) (reflect.Type, bool) {
+	if t.NumIn() != 1 || t.NumOut() != 0 {
+		return nil, false
+	}
+	yield := t.In(0)
+	if yield.Kind() != reflect.Func || yield.NumIn() != 1 || yield.NumOut() != 1 || yield.Out(0).Kind() != reflect.Bool {
+		return nil, false
+	}
+	return yield.In(0), true
+}
+
+// seq2ErrElem returns T if t is the type of an iter.Seq2[T, error].
+func seq2ErrElem(t reflect.Type) (reflect.Type, bool) {
+	if t.NumIn() != 1 || t.NumOut() != 0 {
+		return nil, false
+	}
+	yield := t.In(0)
+	if yield.Kind() != reflect.Func || yield.NumIn() != 2 || yield.NumOut() != 1 || yield.Out(0).Kind() != reflect.Bool || yield.In(1) != errorType {
+		return nil, false
+	}
+	return yield.In(0), true
+}
+
+// streamError is an error yielded by an [iter.Seq2] partway through rendering it.
+type streamError struct {
+	err error
+}
+
+func (e *streamError) Error() string {
+	return fmt.Sprintf("data stream failed: %s", e.err)
+}
+
+func (e *streamError) Unwrap() error {
+	return e.err
+}
+
+// each calls yield with each item of s, and flush every [streamFlushInterval] so that the items rendered so far reach the client.
+//
+// It stops early if yield or flush return an error, ctx is done, or s yields an error, and returns that error.
+func (s stream) each(ctx context.Context, flush func() error, yield func(reflect.Value) error) error {
+	lastFlush := time.Now()
+	var err error
+	step := func(item reflect.Value) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+		if err = yield(item); err != nil {
+			return false
+		}
+		if time.Since(lastFlush) >= streamFlushInterval {
+			if err = flush(); err != nil {
+				return false
+			}
+			lastFlush = time.Now()
+		}
+		return true
+	}
+
+	switch {
+	case s.v.Kind() == reflect.Chan:
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: s.v},
+		}
+		for {
+			chosen, item, ok := reflect.Select(cases)
+			if chosen == 0 {
+				return ctx.Err()
+			}
+			if !ok || !step(item) {
+				break
+			}
+		}
+	case s.withErr:
+		for item, itemErr := range s.v.Seq2() {
+			if !itemErr.IsNil() {
+				return &streamError{itemErr.Interface().(error)}
+			}
+			if !step(item) {
+				break
+			}
+		}
+	default:
+		for item := range s.v.Seq() {
+			if !step(item) {
+				break
+			}
+		}
+	}
+	return err
+}
+
+// seq returns an iter.Seq of the items of s, for templates to range over. Its iteration is stopped by ctx, and flushes w as [stream.each] does.
+//
+// The error that stopped it, if any, is returned by streamErr once the template has executed.
+func (s stream) seq(ctx context.Context, w io.Writer) (seq any, streamErr func() error) {
+	var err error
+	yieldType := reflect.FuncOf([]reflect.Type{s.elem}, []reflect.Type{reflect.TypeFor[bool]()}, false)
+	seqType := reflect.FuncOf([]reflect.Type{yieldType}, nil, false)
+	fn := reflect.MakeFunc(seqType, func(args []reflect.Value) []reflect.Value {
+		yield := args[0]
+		stopped := errors.New("stopped by the template")
+		err = s.each(ctx, func() error { return flushWriter(w) }, func(item reflect.Value) error {
+			if !yield.Call([]reflect.Value{item})[0].Bool() {
+				return stopped
+			}
+			return nil
+		})
+		if err == stopped {
+			err = nil
+		}
+		return nil
+	})
+	return fn.Interface(), func() error { return err }
+}
+
+// templateData returns the data that is passed to templates, which is [stream.seq] if res.Data is a stream.
+func (res *Body) templateData(w io.Writer) (data any, streamErr func() error) {
+	s, ok := asStream(res.Data)
+	if !ok {
+		return res.Data, func() error { return nil }
+	}
+	return s.seq(res.Context(), w)
+}
+
+// flushWriter flushes w, if it is able to be flushed.
+func flushWriter(w io.Writer) error {
+	switch w := w.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case http.ResponseWriter:
+		err := http.NewResponseController(w).Flush()
+		if errors.Is(err, http.ErrNotSupported) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// encodeJsonStream writes the items of s as a JSON array, as [json.Encoder] would write a slice.
+func encodeJsonStream(ctx context.Context, w io.Writer, s stream, cfg Config) error {
+	indented := cfg.JsonPrefix != "" || cfg.JsonIndent != ""
+	sep, end := ",", "]\n"
+	if indented {
+		sep, end = ",\n"+cfg.JsonPrefix+cfg.JsonIndent, "\n"+cfg.JsonPrefix+"]\n"
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	first := true
+	err := s.each(ctx, func() error { return flushWriter(w) }, func(item reflect.Value) error {
+		var b []byte
+		var err error
+		if indented {
+			b, err = json.MarshalIndent(item.Interface(), cfg.JsonPrefix+cfg.JsonIndent, cfg.JsonIndent)
+		} else {
+			b, err = json.Marshal(item.Interface())
+		}
+		if err != nil {
+			return err
+		}
+		if first {
+			first = false
+			if indented {
+				_, err = io.WriteString(w, "\n"+cfg.JsonPrefix+cfg.JsonIndent)
+			}
+		} else {
+			_, err = io.WriteString(w, sep)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if first {
+		end = "]\n"
+	}
+	_, err = io.WriteString(w, end)
+	return err
+}
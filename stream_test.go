@@ -0,0 +1,240 @@
+package rsvp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	html "html/template"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+type streamRow struct {
+	ID   int    `json:"id" csv:"id" xml:"id"`
+	Name string `json:"name" csv:"name" xml:"name"`
+}
+
+func streamRows(n int) iter.Seq[streamRow] {
+	return func(yield func(streamRow) bool) {
+		for i := range n {
+			if !yield(streamRow{ID: i + 1, Name: string(rune('a' + i))}) {
+				return
+			}
+		}
+	}
+}
+
+func renderStream(t *testing.T, path string, data any, cfg rsvp.Config) string {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data(data), cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+	assert.Eq(t, "status", 200, rec.Code)
+
+	return rec.Body.String()
+}
+
+func TestStreamJson(t *testing.T) {
+	actual := renderStream(t, "/rows.json", streamRows(2), rsvp.Config{})
+	assert.Eq(t, "compact", `[{"id":1,"name":"a"},{"id":2,"name":"b"}]`+"\n", actual)
+
+	actual = renderStream(t, "/rows.json", streamRows(2), rsvp.Config{JsonPrefix: "> ", JsonIndent: "  "})
+	var expected strings.Builder
+	enc := json.NewEncoder(&expected)
+	enc.SetIndent("> ", "  ")
+	_ = enc.Encode([]streamRow{{1, "a"}, {2, "b"}})
+	assert.Eq(t, "indented like a slice", expected.String(), actual)
+
+	actual = renderStream(t, "/rows.json", streamRows(0), rsvp.Config{})
+	assert.Eq(t, "empty", "[]\n", actual)
+
+	actual = renderStream(t, "/rows.json", streamRows(0), rsvp.Config{JsonIndent: "  "})
+	assert.Eq(t, "empty indented", "[]\n", actual)
+}
+
+func TestStreamCsv(t *testing.T) {
+	seq2 := func(yield func(*streamRow, error) bool) {
+		for row := range streamRows(2) {
+			if !yield(&row, nil) {
+				return
+			}
+		}
+	}
+
+	actual := renderStream(t, "/rows.csv", iter.Seq2[*streamRow, error](seq2), rsvp.Config{})
+
+	assert.Eq(t, "body", "id,name\n1,a\n2,b\n", actual)
+}
+
+func TestStreamChannel(t *testing.T) {
+	ch := make(chan streamRow, 2)
+	ch <- streamRow{1, "a"}
+	ch <- streamRow{2, "b"}
+	close(ch)
+
+	actual := renderStream(t, "/rows.tsv", (<-chan streamRow)(ch), rsvp.Config{})
+
+	assert.Eq(t, "body", "id\tname\n1\ta\n2\tb\n", actual)
+}
+
+func TestStreamXml(t *testing.T) {
+	actual := renderStream(t, "/rows.xml", streamRows(2), rsvp.Config{XmlRoot: "rows"})
+
+	assert.Eq(t, "body", "<rows><streamRow><id>1</id><name>a</name></streamRow><streamRow><id>2</id><name>b</name></streamRow></rows>", actual)
+}
+
+func TestStreamTemplate(t *testing.T) {
+	cfg := rsvp.Config{HtmlTemplate: html.Must(html.New("rows").Parse(`<ul>{{range .}}<li>{{.Name}}</li>{{end}}</ul>`))}
+	req := httptest.NewRequest("GET", "/rows.html", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Body{Data: streamRows(2), TemplateName: "rows"}, cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "body", "<ul><li>a</li><li>b</li></ul>", rec.Body.String())
+}
+
+func TestStreamMediaTypes(t *testing.T) {
+	res := rsvp.Data(streamRows(1))
+
+	actual := slices.Collect(res.MediaTypes(rsvp.Config{}))
+
+	expected := []string{
+		rsvp.SupportedMediaTypeJson,
+		rsvp.SupportedMediaTypeXml,
+		rsvp.SupportedMediaTypeNdjson,
+		rsvp.SupportedMediaTypeJsonl,
+		rsvp.SupportedMediaTypeJsonSeq,
+		rsvp.SupportedMediaTypeCsv,
+		rsvp.SupportedMediaTypeTsv,
+	}
+	assert.SlicesEq(t, "media types", expected, actual)
+}
+
+var errCursor = errors.New("cursor closed")
+
+func failingRows(yield func(streamRow, error) bool) {
+	if !yield(streamRow{1, "a"}, nil) {
+		return
+	}
+	yield(streamRow{}, errCursor)
+}
+
+func TestStreamErrorIsReported(t *testing.T) {
+	req := httptest.NewRequest("GET", "/rows.csv", nil)
+	rec := httptest.NewRecorder()
+
+	err := serveWithErrorHook(t, rsvp.Config{}, rec, req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(iter.Seq2[streamRow, error](failingRows))
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorStream, err.Kind)
+	assert.True(t, "wraps the yielded error", errors.Is(err, errCursor))
+	assert.Eq(t, "status", 200, rec.Code)
+}
+
+func TestStreamErrorIsReportedFromTemplate(t *testing.T) {
+	cfg := rsvp.Config{HtmlTemplate: html.Must(html.New("rows").Parse(`{{range .}}<p>{{.Name}}</p>{{end}}`))}
+	req := httptest.NewRequest("GET", "/rows.html", nil)
+	rec := httptest.NewRecorder()
+
+	err := serveWithErrorHook(t, cfg, rec, req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Body{Data: iter.Seq2[streamRow, error](failingRows), TemplateName: "rows"}
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorStream, err.Kind)
+	assert.True(t, "wraps the yielded error", errors.Is(err, errCursor))
+	assert.True(t, "rendered the first item", strings.HasPrefix(rec.Body.String(), "<p>a</p>"))
+}
+
+func TestBufferedStreamErrorBecomes500(t *testing.T) {
+	req := httptest.NewRequest("GET", "/rows.json", nil)
+	rec := httptest.NewRecorder()
+
+	err := serveWithErrorHook(t, rsvp.Config{Buffered: true}, rec, req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(iter.Seq2[streamRow, error](failingRows))
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorStream, err.Kind)
+	assert.Eq(t, "status", 500, rec.Code)
+}
+
+func TestStreamStopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequestWithContext(ctx, "GET", "/rows.json", nil)
+	yielded := 0
+	rows := func(yield func(streamRow) bool) {
+		for i := 0; ; i++ {
+			yielded++
+			if i == 2 {
+				cancel()
+			}
+			if !yield(streamRow{ID: i}) {
+				return
+			}
+		}
+	}
+
+	err := serveWithErrorHook(t, rsvp.Config{}, httptest.NewRecorder(), req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(iter.Seq[streamRow](rows))
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorClientDisconnect, err.Kind)
+	assert.Eq(t, "items yielded", 3, yielded)
+}
+
+func TestStreamChannelStopsWhenContextIsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequestWithContext(ctx, "GET", "/rows.json", nil)
+	ch := make(chan streamRow)
+	go func() {
+		ch <- streamRow{ID: 1}
+		cancel()
+	}()
+
+	err := serveWithErrorHook(t, rsvp.Config{}, httptest.NewRecorder(), req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(ch)
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorClientDisconnect, err.Kind)
+}
+
+func TestStreamStopsWhenContextTimesOut(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequestWithContext(ctx, "GET", "/rows.json", nil)
+	ch := make(chan streamRow)
+
+	err := serveWithErrorHook(t, rsvp.Config{}, httptest.NewRecorder(), req, func(w rsvp.ResponseWriter, r *http.Request) rsvp.Body {
+		return rsvp.Data(ch)
+	})
+
+	assert.Eq(t, "kind", rsvp.WriteErrorTimeout, err.Kind)
+}
+
+func TestStreamIsFlushed(t *testing.T) {
+	rows := func(yield func(streamRow) bool) {
+		if !yield(streamRow{ID: 1}) {
+			return
+		}
+		time.Sleep(110 * time.Millisecond)
+		yield(streamRow{ID: 2})
+	}
+	req := httptest.NewRequest("GET", "/rows.csv", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data(iter.Seq[streamRow](rows)), rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.True(t, "flushed", rec.Flushed)
+}
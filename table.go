@@ -0,0 +1,178 @@
+package rsvp
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"iter"
+	"strings"
+	"text/tabwriter"
+)
+
+const SupportedMediaTypeMarkdown string = "text/markdown"
+
+// Table is tabular data. Implementing it offers [Body.Data] as text/csv, text/tab-separated-values, text/markdown, and as a table in text/html and text/plain.
+//
+// The HTML and plain text tables are only used when no template matches [Body.TemplateName]. [Csv] and [CsvOptionsMarshaler] take precedence for text/csv and text/tab-separated-values.
+type Table interface {
+	// Columns are the column headers.
+	Columns() []string
+	// Rows yields each row of cells, which should be as long as Columns.
+	Rows() iter.Seq[[]string]
+}
+
+// marshalTable writes the columns of table, unless the header row is omitted by opts, followed by its rows.
+func marshalTable(w interface{ Write([]string) error }, table Table, opts CsvOptions) error {
+	if !opts.OmitHeader {
+		err := w.Write(table.Columns())
+		if err != nil {
+			return err
+		}
+	}
+	for row := range table.Rows() {
+		err := w.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type markdownTableCodec struct{}
+
+func (markdownTableCodec) MediaType() string    { return SupportedMediaTypeMarkdown }
+func (markdownTableCodec) ContentType() string  { return "text/markdown; charset=utf-8" }
+func (markdownTableCodec) Extensions() []string { return []string{"md"} }
+
+func (markdownTableCodec) CanEncode(res *Body, cfg Config) bool {
+	_, ok := res.Data.(Table)
+	return ok
+}
+
+var markdownCellReplacer = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+func writeMarkdownRow(w io.Writer, cells []string) error {
+	var b strings.Builder
+	b.WriteString("|")
+	for _, cell := range cells {
+		b.WriteString(" ")
+		b.WriteString(markdownCellReplacer.Replace(cell))
+		b.WriteString(" |")
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (markdownTableCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	table, ok := res.Data.(Table)
+	if !ok {
+		return fmt.Errorf("trying to write %#v, but it does not implement rsvp.Table", res.Data)
+	}
+
+	columns := table.Columns()
+	err := writeMarkdownRow(w, columns)
+	if err != nil {
+		return fmt.Errorf("rendering data as markdown: %w", err)
+	}
+	_, err = io.WriteString(w, "|"+strings.Repeat(" --- |", len(columns))+"\n")
+	if err != nil {
+		return fmt.Errorf("rendering data as markdown: %w", err)
+	}
+	for row := range table.Rows() {
+		err = writeMarkdownRow(w, row)
+		if err != nil {
+			return fmt.Errorf("rendering data as markdown: %w", err)
+		}
+	}
+	return nil
+}
+
+type htmlTableCodec struct{}
+
+func (htmlTableCodec) MediaType() string    { return SupportedMediaTypeHtml }
+func (htmlTableCodec) ContentType() string  { return "text/html; charset=utf-8" }
+func (htmlTableCodec) Extensions() []string { return []string{"html", "htm"} }
+
+func (htmlTableCodec) CanEncode(res *Body, cfg Config) bool {
+	_, ok := res.Data.(Table)
+	return ok
+}
+
+func writeHtmlRow(b *strings.Builder, cell string, cells []string) {
+	b.WriteString("<tr>")
+	for _, c := range cells {
+		fmt.Fprintf(b, "<%s>%s</%s>", cell, html.EscapeString(c), cell)
+	}
+	b.WriteString("</tr>\n")
+}
+
+func (htmlTableCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	table, ok := res.Data.(Table)
+	if !ok {
+		return fmt.Errorf("trying to write %#v, but it does not implement rsvp.Table", res.Data)
+	}
+
+	var b strings.Builder
+	b.WriteString("<table>\n<thead>\n")
+	writeHtmlRow(&b, "th", table.Columns())
+	b.WriteString("</thead>\n<tbody>\n")
+	for row := range table.Rows() {
+		writeHtmlRow(&b, "td", row)
+	}
+	b.WriteString("</tbody>\n</table>\n")
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return fmt.Errorf("rendering data as an HTML table: %w", err)
+	}
+	return nil
+}
+
+type plainTableCodec struct{}
+
+func (plainTableCodec) MediaType() string    { return SupportedMediaTypePlaintext }
+func (plainTableCodec) ContentType() string  { return "text/plain; charset=utf-8" }
+func (plainTableCodec) Extensions() []string { return []string{"txt"} }
+
+func (plainTableCodec) CanEncode(res *Body, cfg Config) bool {
+	_, ok := res.Data.(Table)
+	return ok
+}
+
+var plainCellReplacer = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ")
+
+// tabwriterRows adapts a [tabwriter.Writer] to marshalTable.
+type tabwriterRows struct {
+	w *tabwriter.Writer
+}
+
+func (t tabwriterRows) Write(cells []string) error {
+	var b strings.Builder
+	for i, cell := range cells {
+		if i > 0 {
+			b.WriteString("\t")
+		}
+		b.WriteString(plainCellReplacer.Replace(cell))
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(t.w, b.String())
+	return err
+}
+
+func (plainTableCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	table, ok := res.Data.(Table)
+	if !ok {
+		return fmt.Errorf("trying to write %#v, but it does not implement rsvp.Table", res.Data)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	err := marshalTable(tabwriterRows{tw}, table, CsvOptions{})
+	if err == nil {
+		err = tw.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("rendering data as a plain text table: %w", err)
+	}
+	return nil
+}
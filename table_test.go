@@ -0,0 +1,135 @@
+package rsvp_test
+
+import (
+	"encoding/csv"
+	html "html/template"
+	"iter"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+type planets [][]string
+
+func (p planets) Columns() []string {
+	return []string{"Name", "Moons"}
+}
+
+func (p planets) Rows() iter.Seq[[]string] {
+	return slices.Values(p)
+}
+
+var solarSystem = planets{{"Earth", "1"}, {"Mars | Red", "2"}, {"<Jupiter>", "95"}}
+
+func renderTable(t *testing.T, cfg rsvp.Config, res rsvp.Body, accept string) (contentType, body string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", accept)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+	assert.Eq(t, "status", 200, rec.Code)
+
+	return rec.Header().Get("Content-Type"), rec.Body.String()
+}
+
+func TestTableMediaTypes(t *testing.T) {
+	res := rsvp.Data(solarSystem)
+	actual := slices.Collect(res.MediaTypes(rsvp.Config{}))
+
+	expected := []string{
+		rsvp.SupportedMediaTypeJson,
+		rsvp.SupportedMediaTypeXml,
+		rsvp.SupportedMediaTypeNdjson,
+		rsvp.SupportedMediaTypeJsonl,
+		rsvp.SupportedMediaTypeJsonSeq,
+		rsvp.SupportedMediaTypeCsv,
+		rsvp.SupportedMediaTypeTsv,
+		rsvp.SupportedMediaTypeMarkdown,
+		rsvp.SupportedMediaTypeHtml,
+		rsvp.SupportedMediaTypePlaintext,
+	}
+
+	assert.SlicesEq(t, "media types", expected, actual)
+}
+
+func TestTableCsv(t *testing.T) {
+	contentType, body := renderTable(t, rsvp.Config{}, rsvp.Data(solarSystem), "text/csv")
+
+	assert.Eq(t, "content type", "text/csv; charset=utf-8; header=present", contentType)
+	assert.Eq(t, "body", "Name,Moons\nEarth,1\nMars | Red,2\n<Jupiter>,95\n", body)
+
+	_, body = renderTable(t, rsvp.Config{}, rsvp.Data(solarSystem), "text/tab-separated-values")
+	assert.Eq(t, "tsv body", "Name\tMoons\nEarth\t1\nMars | Red\t2\n<Jupiter>\t95\n", body)
+}
+
+func TestTableMarkdown(t *testing.T) {
+	contentType, body := renderTable(t, rsvp.Config{}, rsvp.Data(solarSystem), "text/markdown")
+
+	assert.Eq(t, "content type", "text/markdown; charset=utf-8", contentType)
+	expected := `| Name | Moons |
+| --- | --- |
+| Earth | 1 |
+| Mars \| Red | 2 |
+| <Jupiter> | 95 |
+`
+	assert.Eq(t, "body", expected, body)
+}
+
+func TestTablePlain(t *testing.T) {
+	contentType, body := renderTable(t, rsvp.Config{}, rsvp.Data(solarSystem), "text/plain")
+
+	assert.Eq(t, "content type", "text/plain; charset=utf-8", contentType)
+	expected := `Name        Moons
+Earth       1
+Mars | Red  2
+<Jupiter>   95
+`
+	assert.Eq(t, "body", expected, body)
+	assert.Eq(t, "rows are not modified", "Mars | Red", solarSystem[1][0])
+}
+
+func TestTableHtml(t *testing.T) {
+	contentType, body := renderTable(t, rsvp.Config{}, rsvp.Data(solarSystem), "text/html")
+
+	assert.Eq(t, "content type", "text/html; charset=utf-8", contentType)
+	expected := `<table>
+<thead>
+<tr><th>Name</th><th>Moons</th></tr>
+</thead>
+<tbody>
+<tr><td>Earth</td><td>1</td></tr>
+<tr><td>Mars | Red</td><td>2</td></tr>
+<tr><td>&lt;Jupiter&gt;</td><td>95</td></tr>
+</tbody>
+</table>
+`
+	assert.Eq(t, "body", expected, body)
+}
+
+func TestTableHtmlTemplateTakesPrecedence(t *testing.T) {
+	cfg := rsvp.Config{HtmlTemplate: html.Must(html.New("planets").Parse(`{{len .}} planets`))}
+
+	_, body := renderTable(t, cfg, rsvp.Body{Data: solarSystem, TemplateName: "planets"}, "text/html")
+
+	assert.Eq(t, "body", "3 planets", body)
+}
+
+type csvPlanets struct {
+	planets
+}
+
+func (csvPlanets) MarshalCsv(w *csv.Writer) error {
+	return w.Write([]string{"custom"})
+}
+
+func TestTableCsvInterfaceTakesPrecedence(t *testing.T) {
+	contentType, body := renderTable(t, rsvp.Config{}, rsvp.Data(csvPlanets{solarSystem}), "text/csv")
+
+	assert.Eq(t, "content type", "text/csv; charset=utf-8", contentType)
+	assert.Eq(t, "body", "custom\n", body)
+}
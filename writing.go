@@ -1,18 +1,14 @@
 package rsvp
 
 import (
-	"cmp"
-	"encoding/csv"
-	"encoding/json"
-	"encoding/xml"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"slices"
-
-	"github.com/Teajey/rsvp/internal/content"
-	"github.com/Teajey/rsvp/internal/dev"
+	"strconv"
+	"sync"
 )
 
 // ResponseWriter handles metadata and configuration of the response. It bears its "Writer" name mostly for the sake of keeping rsvp.Handler similar to http.Handler.
@@ -32,18 +28,33 @@ type ResponseWriter interface {
 
 // Write the result of handler to w. May write headers to w.Header().
 //
+// Any error that is returned wraps a [*WriteError].
+//
 // NOTE: This function is for advanced lower-level use cases.
 func Write(w http.ResponseWriter, r *http.Request, cfg Config, handler Handler) error {
 	rw := responseWriter{
 		writer: w,
 	}
-	response := handler.ServeHTTP(&rw, r)
-	return rw.write(&response, r, cfg)
+	return rw.serve(r, cfg, handler)
 }
 
 type responseWriter struct {
 	writer              http.ResponseWriter
 	defaultTemplateName string
+	// renderFailed is set once a response has failed, so that a failing fallback is not retried.
+	renderFailed bool
+	// wroteHeader is set once the status code has been written, after which the response can no longer be replaced.
+	wroteHeader bool
+}
+
+func (w *responseWriter) serve(r *http.Request, cfg Config, handler Handler) error {
+	response := handler.ServeHTTP(w, r)
+	return w.write(&response, r, cfg)
+}
+
+func (w *responseWriter) writeHeader(status int) {
+	w.wroteHeader = true
+	w.writer.WriteHeader(status)
 }
 
 func (w *responseWriter) DefaultTemplateName(name string) {
@@ -56,179 +67,196 @@ func (w *responseWriter) Header() http.Header {
 
 // Write the [Body] to the [http.ResponseWriter] with the given [Config].
 func (w *responseWriter) write(res *Body, r *http.Request, cfg Config) (err error) {
-	w.writer.Header().Add("Vary", "Accept")
-
-	dev.Log("config: %#v", cfg)
-	status := cmp.Or(res.statusCode, 200)
+	addVary(w.writer.Header(), "Accept")
 
-	if res.TemplateName == "" && w.defaultTemplateName != "" {
-		dev.Log("Using default template name: %v", w.defaultTemplateName)
-		res.TemplateName = w.defaultTemplateName
-	}
-
-	accept := r.Header.Get("Accept")
+	ctx := r.Context()
+	res.resolveTemplateName(ctx, w.defaultTemplateName, cfg)
 
 	wh := w.Header()
 
 	contentType := wh.Get("Content-Type")
 	if contentType != "" {
 		aMediaType := string(contentTypeExtractMediaType(contentType))
-		_, ok := mediaTypeToContentType[aMediaType]
+		_, ok := cfg.contentType(aMediaType)
 		if ok {
+			if suffix, ok := suffixMediaType(aMediaType); ok && cfg.lookupEncoder(aMediaType, res) == nil {
+				// e.g. a hand-built RSS struct with Content-Type: application/rss+xml is rendered as XML
+				aMediaType = suffix
+			}
 			res.predeterminedMediaType = aMediaType
-			dev.Log("Content-Type is set to a recognised type, so predeterminedMediaType set to %#v", res.predeterminedMediaType)
+			cfg.debug(ctx, "Content-Type is already set to a recognised media type, so it will not be negotiated", "media_type", aMediaType)
 		}
 	}
 
-	ext := determineExt(r)
-	supported := res.determineSupported(cfg)
-	mediaType := res.determineMediaType(ext, accept, supported)
-
-	if 300 <= res.statusCode && res.statusCode < 400 {
-		dev.Log("Redirect")
-
-		wh.Set("Location", res.redirectLocation)
-
-		if res.isBlank() {
-			dev.Log("Redirect returning empty")
-			w.writer.WriteHeader(status)
-			return
-		}
-
-		supported := res.determineSupported(cfg)
-		mediaType := res.determineMediaType(ext, accept, supported)
-
-		res.determineContentType(mediaType, wh)
-
-		w.writer.WriteHeader(status)
-		err = render(res, mediaType, w.writer, cfg)
-		return
+	var x *Explanation
+	if cfg.ExplainHeader != "" {
+		x = &Explanation{}
 	}
-
-	if ext != "" {
-		a, ok := extToProposalMap[ext]
-		if !ok || !slices.Contains(supported, a) {
-			status = http.StatusNotFound
-		}
+	mediaType, status := res.negotiateResponse(r, cfg, x)
+	if x != nil {
+		wh.Set(cfg.ExplainHeader, x.String())
 	}
 
-	if mediaType == "" {
-		dev.Log("NotAcceptable. Ignoring Accept header and setting status code to 406...")
-		status = http.StatusNotAcceptable
-		mediaType = chooseMediaType(ext, supported, content.ParseAccept(""))
-		dev.Log("new mediaType %#v", mediaType)
+	if res.isRedirect() {
+		cfg.debug(ctx, "redirecting", "status", status, "location", res.redirectLocation)
+		wh.Set("Location", res.redirectLocation)
 	}
 
 	if !res.isBlank() && contentType == "" {
-		res.determineContentType(mediaType, wh)
+		res.determineContentType(ctx, mediaType, wh, cfg)
 	}
 
 	if res.isBlank() {
-		dev.Log("Early returning because body is empty")
-		w.writer.WriteHeader(status)
+		cfg.debug(ctx, "writing blank response", "status", status)
+		w.writeHeader(status)
 		return
 	}
 
-	w.writer.WriteHeader(status)
-	err = render(res, mediaType, w.writer, cfg)
+	err = w.send(res, status, mediaType, r, cfg)
 	return
 }
 
-const templateErrorMessage = "rsvp stopped writing here because of a template error"
+// maxPooledBufferSize stops unusually large responses from being held onto by bufferPool.
+const maxPooledBufferSize = 1 << 20
 
-func render(res *Body, mediaType string, w io.Writer, cfg Config) error {
-	switch mediaType {
-	case SupportedMediaTypeHtml:
-		dev.Log("Rendering html...")
-		if res.TemplateName != "" && cfg.HtmlTemplate != nil {
-			dev.Log("Template name is set, so expecting a template...")
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
 
-			if tm := cfg.HtmlTemplate.Lookup(res.TemplateName); tm != nil {
-				dev.Log("Executing HtmlTemplate...")
-				err := tm.ExecuteTemplate(w, res.TemplateName, res.Data)
-				if err != nil {
-					_, _ = fmt.Fprintf(w, `<span style="background-color: red; color: white;">%s</span>`, templateErrorMessage)
-					return fmt.Errorf("rendering data in html template %s: %w", res.TemplateName, err)
-				}
-				break
-			}
+// send writes the status and renders res, buffering the rendered body if [Config.Buffered] is set.
+func (w *responseWriter) send(res *Body, status int, mediaType string, r *http.Request, cfg Config) error {
+	if !cfg.Buffered {
+		w.writeHeader(status)
+		return withStatus(render(r.Context(), res, mediaType, w.writer, cfg), status)
+	}
 
-			return errors.New("failed to match TemplateName within HtmlTemplate")
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(buf)
 		}
-		return fmt.Errorf("failed to render HTML because either HtmlTemplate or TemplateName is not set")
-	case SupportedMediaTypePlaintext:
-		dev.Log("Rendering plain text...")
-
-		if res.TemplateName != "" && cfg.TextTemplate != nil {
-			dev.Log("Template name is set, so looking for a template...")
-
-			if tm := cfg.TextTemplate.Lookup(res.TemplateName); tm != nil {
-				dev.Log("Executing TextTemplate...")
-				err := tm.ExecuteTemplate(w, res.TemplateName, res.Data)
-				if err != nil {
-					_, _ = fmt.Fprintf(w, "[!!ERROR!!][%s]", templateErrorMessage)
-					return fmt.Errorf("rendering data in text template %s: %w", res.TemplateName, err)
-				}
-				break
-			}
+	}()
+
+	err := render(r.Context(), res, mediaType, buf, cfg)
+	if err != nil {
+		cfg.debug(r.Context(), "discarding buffered response because rendering failed", "media_type", mediaType, "error", err)
+		err = withStatus(err, status)
+		fallbackErr := w.writeFailure(r, cfg)
+		if fallbackErr != nil {
+			return errors.Join(err, fallbackErr)
 		}
-		dev.Log("Not using a template because either TemplateName is not set, or it did not find a match...")
+		return err
+	}
 
-		if data, ok := res.Data.(string); ok {
-			dev.Log("Can write data directly because it is a string...")
-			_, err := w.Write([]byte(data))
-			if err != nil {
-				return fmt.Errorf("rendering data as plain string: %w", err)
-			}
-			break
+	w.writer.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.writeHeader(status)
+	_, err = buf.WriteTo(w.writer)
+	if err != nil {
+		return &WriteError{
+			Kind:      WriteErrorClientDisconnect,
+			MediaType: mediaType,
+			Status:    status,
+			Err:       fmt.Errorf("writing buffered response: %w", err),
 		}
+	}
 
-		return fmt.Errorf("trying to render data as %v but this type is not supported: %#v", SupportedMediaTypePlaintext, res.Data)
-	case SupportedMediaTypeJson:
-		dev.Log("Rendering json...")
-		enc := json.NewEncoder(w)
-		enc.SetIndent(cfg.JsonPrefix, cfg.JsonIndent)
-		err := enc.Encode(res.Data)
-		if err != nil {
-			return fmt.Errorf("rendering data as JSON: %w", err)
-		}
-	case SupportedMediaTypeXml:
-		dev.Log("Rendering xml...")
-		enc := xml.NewEncoder(w)
-		enc.Indent(cfg.XmlPrefix, cfg.XmlIndent)
-		err := enc.Encode(res.Data)
-		if err != nil {
-			return fmt.Errorf("rendering data as XML: %w", err)
-		}
-	case SupportedMediaTypeCsv:
-		data, ok := res.Data.(Csv)
-		if !ok {
-			return fmt.Errorf("trying to write %#v, but it does not implement rsvp.Csv", res.Data)
-		}
-		dev.Log("Rendering csv...")
-		wr := csv.NewWriter(w)
-		err := data.MarshalCsv(wr)
-		if err != nil {
-			return fmt.Errorf("rendering data as CSV: %w", err)
-		}
-	case SupportedMediaTypeBytes:
-		dev.Log("Rendering bytes...")
-		_, err := w.Write(res.Data.([]byte))
-		if err != nil {
-			return fmt.Errorf("rendering data as bytes: %w", err)
+	return nil
+}
+
+// writeFailure writes a negotiated 500 [Problem] in place of a response that failed to render, or whose handler panicked.
+//
+// If the 500 also fails to render, a plain text 500 is written instead.
+func (w *responseWriter) writeFailure(r *http.Request, cfg Config) error {
+	wh := w.writer.Header()
+	wh.Del("Content-Type")
+	wh.Del("Content-Length")
+	wh.Del("Location")
+
+	if w.renderFailed {
+		cfg.debug(r.Context(), "the fallback response also failed to render")
+		w.wroteHeader = true
+		http.Error(w.writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return nil
+	}
+	w.renderFailed = true
+
+	// The handler's template is likely the reason rendering failed
+	w.defaultTemplateName = ""
+	fallback := NewProblem(http.StatusInternalServerError, "").Body()
+	fallback.keepStatus = true
+	return w.write(&fallback, r, cfg)
+}
+
+func (res *Body) resolveTemplateName(ctx context.Context, defaultTemplateName string, cfg Config) {
+	if _, ok := asProblem(res.Data); ok && res.TemplateName == "" && cfg.ProblemTemplateName != "" {
+		cfg.debug(ctx, "using problem template name", "template", cfg.ProblemTemplateName)
+		res.TemplateName = cfg.ProblemTemplateName
+	}
+
+	if res.TemplateName == "" && defaultTemplateName != "" {
+		cfg.debug(ctx, "using default template name", "template", defaultTemplateName)
+		res.TemplateName = defaultTemplateName
+	}
+}
+
+func render(ctx context.Context, res *Body, mediaType string, w io.Writer, cfg Config) error {
+	codec := cfg.lookupEncoder(mediaType, res)
+	if codec == nil {
+		err := fmt.Errorf("trying to render data as %v but this type is not supported: %#v", mediaType, res.Data)
+		if cfg.lookupCodec(mediaType) == nil {
+			err = fmt.Errorf("unhandled mediaType: %#v", mediaType)
 		}
-	default:
-		for _, handler := range mediaTypeExtensionHandlers {
-			matched, err := handler(mediaType, w, res)
-			if err != nil {
-				return fmt.Errorf("an extension handler failed: %w", err)
-			}
-			if matched {
-				return nil
-			}
+		return &WriteError{Kind: WriteErrorUnsupportedMediaType, MediaType: mediaType, Err: err}
+	}
+
+	if cfg.debugEnabled(ctx) {
+		cfg.debug(ctx, "rendering response", "media_type", mediaType, "codec", fmt.Sprintf("%T", codec), "template", res.TemplateName)
+	}
+	res.ctx = ctx
+	ew := errWriter{w: w}
+	err := codec.Encode(&ew, res, cfg)
+	if err != nil {
+		kind := renderErrorKind(codec, err)
+		if ew.err != nil {
+			kind = WriteErrorClientDisconnect
 		}
-		return fmt.Errorf("unhandled mediaType: %#v", mediaType)
+		return &WriteError{Kind: kind, MediaType: mediaType, Err: err}
 	}
 
 	return nil
 }
+
+// withStatus records status on the [WriteError] wrapped by err.
+func withStatus(err error, status int) error {
+	var werr *WriteError
+	if errors.As(err, &werr) {
+		werr.Status = status
+	}
+	return err
+}
+
+// errWriter remembers the first error returned by w, so that a failure to reach the client can be told apart from a failure to encode.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+// Flush flushes w if it is able to be flushed, so that a stream reaches the client as it is rendered.
+func (e *errWriter) Flush() error {
+	err := flushWriter(e.w)
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+	return err
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	n, err := e.w.Write(p)
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,457 @@
+package rsvp
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DefaultXmlRoot is the name of the root element of application/xml responses whose data has no element name of its own. See [Config.XmlRoot].
+const DefaultXmlRoot = "data"
+
+// xmlItem names the elements of collections whose items have no element name of their own, e.g. the strings of a []string.
+const xmlItem = "item"
+
+// xmlRoot resolves the root element name of res, which is [Config.XmlRoot] unless overridden by [Body.XmlRoot].
+func (res *Body) xmlRoot(cfg Config) string {
+	return cmp.Or(res.xmlRootName, cfg.XmlRoot, DefaultXmlRoot)
+}
+
+// XmlRoot overrides [Config.XmlRoot] for this Body only, e.g. to render a []User as <users>...</users>
+func (res Body) XmlRoot(name string) Body {
+	res.xmlRootName = name
+	return res
+}
+
+// XmlOptions control the prolog and root element of application/xml responses. See [Config.XmlOptions] and [Body.XmlOptions].
+type XmlOptions struct {
+	// Declaration writes <?xml version="1.0" encoding="UTF-8"?> before the root element.
+	Declaration bool
+	// Stylesheet is the href of an <?xml-stylesheet?> processing instruction, which lets browsers render the document, e.g. "/feed.xsl".
+	Stylesheet string
+	// StylesheetType is the type of Stylesheet. Defaults to text/css if Stylesheet ends with .css, otherwise text/xsl.
+	StylesheetType string
+	// Namespace is declared as the default namespace of the root element, unless its name already has a namespace.
+	Namespace string
+	// Namespaces are declared on the root element by prefix, e.g. {"atom": "http://www.w3.org/2005/Atom"} declares xmlns:atom="http://www.w3.org/2005/Atom".
+	Namespaces map[string]string
+}
+
+// xmlOptions resolves the options of res, which are [Config.XmlOptions] unless overridden by [Body.XmlOptions].
+func (res *Body) xmlOptions(cfg Config) XmlOptions {
+	if res.xmlOpts != nil {
+		return *res.xmlOpts
+	}
+	return cfg.XmlOptions
+}
+
+// XmlOptions overrides [Config.XmlOptions] for this Body only.
+func (res Body) XmlOptions(opts XmlOptions) Body {
+	res.xmlOpts = &opts
+	return res
+}
+
+// writeProlog writes the XML declaration and stylesheet processing instruction, each on its own line.
+func (opts XmlOptions) writeProlog(enc *xml.Encoder) error {
+	var prolog []xml.Token
+	if opts.Declaration {
+		prolog = append(prolog, xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)})
+	}
+	if opts.Stylesheet != "" {
+		typ := opts.StylesheetType
+		if typ == "" {
+			typ = "text/xsl"
+			if strings.HasSuffix(opts.Stylesheet, ".css") {
+				typ = "text/css"
+			}
+		}
+		inst := fmt.Sprintf(`type="%s" href="%s"`, escapeXmlAttr(typ), escapeXmlAttr(opts.Stylesheet))
+		prolog = append(prolog, xml.ProcInst{Target: "xml-stylesheet", Inst: []byte(inst)})
+	}
+	for _, t := range prolog {
+		if err := enc.EncodeToken(t); err != nil {
+			return err
+		}
+		if err := enc.EncodeToken(xml.CharData("\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// declareNamespaces adds the namespaces of opts to start, which must be the root element. Prefixes that start already declares are skipped, so that they are not declared twice.
+func (opts XmlOptions) declareNamespaces(start *xml.StartElement) {
+	if start.Name.Space == "" {
+		start.Name.Space = opts.Namespace
+	}
+	for _, prefix := range slices.Sorted(maps.Keys(opts.Namespaces)) {
+		declared := slices.ContainsFunc(start.Attr, func(a xml.Attr) bool {
+			return a.Name.Local == "xmlns:"+prefix || (a.Name.Space == "xmlns" && a.Name.Local == prefix)
+		})
+		if declared {
+			continue
+		}
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: opts.Namespaces[prefix]})
+	}
+}
+
+func escapeXmlAttr(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) MediaType() string    { return SupportedMediaTypeXml }
+func (xmlCodec) ContentType() string  { return "application/xml" }
+func (xmlCodec) Extensions() []string { return []string{"xml"} }
+
+func (xmlCodec) CanEncode(res *Body, cfg Config) bool {
+	if s, ok := asStream(res.Data); ok {
+		// The items of a stream can't be inspected before they are rendered, so only their type is checked
+		return canMarshalXml(reflect.Zero(s.elem), false)
+	}
+	if p, ok := asProblem(res.Data); ok {
+		// Problem is an xml.Marshaler, but its extension members may not be representable
+		return p.canMarshalXml()
+	}
+	return canMarshalXml(reflect.ValueOf(res.Data), false)
+}
+
+func (xmlCodec) Encode(w io.Writer, res *Body, cfg Config) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent(cfg.XmlPrefix, cfg.XmlIndent)
+	opts := res.xmlOptions(cfg)
+	err := opts.writeProlog(enc)
+	if err == nil {
+		if s, ok := asStream(res.Data); ok {
+			err = marshalXmlStream(res.Context(), w, enc, s, res.xmlRoot(cfg), opts)
+		} else {
+			err = marshalXmlRoot(enc, reflect.ValueOf(res.Data), res.xmlRoot(cfg), opts)
+		}
+	}
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("rendering data as XML: %w", err)
+	}
+	return nil
+}
+
+var xmlMarshalerType = reflect.TypeFor[xml.Marshaler]()
+
+// implementsXml reports whether v, or a pointer to it, implements t.
+func implementsXml(v reflect.Value, t reflect.Type) bool {
+	return v.Type().Implements(t) || (v.CanAddr() && reflect.PointerTo(v.Type()).Implements(t))
+}
+
+// xmlSupport is what the type of a value says about whether it can be rendered by marshalXml.
+type xmlSupport int
+
+const (
+	// xmlDepends means that the value must be inspected, e.g. because it is an interface, or a map whose keys may not be valid element names.
+	xmlDepends xmlSupport = iota
+	xmlAlways
+	xmlNever
+)
+
+type xmlSupportKey struct {
+	t        reflect.Type
+	inStruct bool
+}
+
+// xmlSupportCache maps an xmlSupportKey to the xmlSupport of its type.
+var xmlSupportCache sync.Map
+
+// xmlTypeSupport decides from t alone whether its values can be rendered by marshalXml, if it can.
+func xmlTypeSupport(t reflect.Type, inStruct bool) xmlSupport {
+	key := xmlSupportKey{t, inStruct}
+	if cached, found := xmlSupportCache.Load(key); found {
+		return cached.(xmlSupport)
+	}
+
+	support := decideXmlTypeSupport(t, inStruct, nil)
+	xmlSupportCache.Store(key, support)
+	return support
+}
+
+// decideXmlTypeSupport implements xmlTypeSupport. visiting holds the types that are being decided, so that recursive types are inspected by value instead.
+func decideXmlTypeSupport(t reflect.Type, inStruct bool, visiting []reflect.Type) xmlSupport {
+	if t.Implements(xmlMarshalerType) || t.Implements(textMarshalerType) {
+		return xmlAlways
+	}
+	if reflect.PointerTo(t).Implements(xmlMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		// Only addressable values use the methods of the pointer
+		return xmlDepends
+	}
+	if slices.Contains(visiting, t) {
+		return xmlDepends
+	}
+	visiting = append(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if decideXmlTypeSupport(t.Elem(), inStruct, visiting) == xmlAlways {
+			return xmlAlways
+		}
+		// A nil pointer is always representable
+		return xmlDepends
+	case reflect.Interface:
+		return xmlDepends
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return xmlNever
+	case reflect.Map:
+		if inStruct || t.Key().Kind() != reflect.String {
+			return xmlNever
+		}
+		// Each key must be a valid element name
+		return xmlDepends
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return xmlAlways
+		}
+		if decideXmlTypeSupport(t.Elem(), inStruct, visiting) == xmlAlways {
+			return xmlAlways
+		}
+		// An empty collection is always representable
+		return xmlDepends
+	case reflect.Struct:
+		support := xmlAlways
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if (!f.IsExported() && !f.Anonymous) || f.Tag.Get("xml") == "-" {
+				continue
+			}
+			switch decideXmlTypeSupport(f.Type, true, visiting) {
+			case xmlNever:
+				return xmlNever
+			case xmlDepends:
+				support = xmlDepends
+			}
+		}
+		return support
+	}
+	return xmlAlways
+}
+
+// canMarshalXml reports whether v can be rendered by marshalXml. Maps are only representable outside of structs, because [xml.Encoder] cannot encode struct fields that are maps.
+//
+// It is decided by the type of v where possible, so that only interfaces, map keys and the like are inspected.
+func canMarshalXml(v reflect.Value, inStruct bool) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch xmlTypeSupport(v.Type(), inStruct) {
+	case xmlAlways:
+		return true
+	case xmlNever:
+		return false
+	}
+	if implementsXml(v, xmlMarshalerType) || implementsXml(v, textMarshalerType) {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil() || canMarshalXml(v.Elem(), inStruct)
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return false
+	case reflect.Map:
+		if inStruct || v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if !isXmlName(iter.Key().String()) || !canMarshalXml(iter.Value(), false) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return true
+		}
+		for i := range v.Len() {
+			if !canMarshalXml(v.Index(i), inStruct) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if (!f.IsExported() && !f.Anonymous) || f.Tag.Get("xml") == "-" {
+				continue
+			}
+			if !canMarshalXml(v.Field(i), true) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// isXmlName reports whether s may be used as the name of an element, so that it is safe to use a map key as one.
+func isXmlName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if unicode.IsLetter(r) || r == '_' {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// marshalXmlRoot encodes v as the root element, which is named name unless v is a struct or implements [xml.Marshaler], and declares the namespaces of opts on it.
+//
+// An xml.Marshaler writes its own root element, so namespaces are not declared on it.
+func marshalXmlRoot(enc *xml.Encoder, v reflect.Value, name string, opts XmlOptions) error {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && !v.IsNil() && !implementsXml(v, xmlMarshalerType) {
+		v = v.Elem()
+	}
+	if v.IsValid() && implementsXml(v, xmlMarshalerType) {
+		return enc.Encode(v.Interface())
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if v.IsValid() && v.Kind() == reflect.Struct && !implementsXml(v, textMarshalerType) {
+		start.Name = xmlStructName(v, name)
+	}
+	opts.declareNamespaces(&start)
+	return marshalXml(enc, v, start, true)
+}
+
+// marshalXmlStream encodes the items of s within a root element named name, as marshalXml encodes a slice, and flushes them to w periodically.
+func marshalXmlStream(ctx context.Context, w io.Writer, enc *xml.Encoder, s stream, name string, opts XmlOptions) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	opts.declareNamespaces(&start)
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	flush := func() error {
+		if err := enc.Flush(); err != nil {
+			return err
+		}
+		return flushWriter(w)
+	}
+	item := xml.StartElement{Name: xml.Name{Local: xmlItem}}
+	err := s.each(ctx, flush, func(v reflect.Value) error {
+		return marshalXml(enc, v, item, false)
+	})
+	if err != nil {
+		return err
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlStructName returns the name that [xml.Marshal] gives to the struct v: that of its XMLName field's tag or value, otherwise its type name without any type arguments.
+// Anonymous structs, which [xml.Marshal] cannot name, are named fallback.
+func xmlStructName(v reflect.Value, fallback string) xml.Name {
+	f, ok := v.Type().FieldByName("XMLName")
+	if ok {
+		tag, _, _ := strings.Cut(f.Tag.Get("xml"), ",")
+		if tag != "" {
+			space, local, ok := strings.Cut(tag, " ")
+			if !ok {
+				return xml.Name{Local: tag}
+			}
+			return xml.Name{Space: space, Local: local}
+		}
+		fv, err := v.FieldByIndexErr(f.Index)
+		if err == nil && fv.CanInterface() {
+			if name, ok := fv.Interface().(xml.Name); ok && name.Local != "" {
+				return name
+			}
+		}
+	}
+	name, _, _ := strings.Cut(v.Type().Name(), "[")
+	if name == "" {
+		name = fallback
+	}
+	return xml.Name{Local: name}
+}
+
+// marshalXml encodes v as a single element.
+//
+// Structs and values that implement [xml.Marshaler] are named as [xml.Marshal] would name them, unless rename is set; anonymous structs are named by start. Everything else is named by start:
+// collections contain an element for each item, maps contain an element for each key in sorted order, and other values become character data.
+func marshalXml(enc *xml.Encoder, v reflect.Value, start xml.StartElement, rename bool) error {
+	if !v.IsValid() {
+		return encodeXmlEmpty(enc, start)
+	}
+	if implementsXml(v, xmlMarshalerType) {
+		if rename {
+			return enc.EncodeElement(v.Interface(), start)
+		}
+		return enc.Encode(v.Interface())
+	}
+	if implementsXml(v, textMarshalerType) {
+		return enc.EncodeElement(v.Interface(), start)
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return encodeXmlEmpty(enc, start)
+		}
+		return marshalXml(enc, v.Elem(), start, rename)
+	case reflect.Struct:
+		if !rename {
+			start = xml.StartElement{Name: xmlStructName(v, start.Name.Local)}
+		}
+		return enc.EncodeElement(v.Interface(), start)
+	case reflect.Map:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return cmp.Compare(a.String(), b.String())
+		})
+		for _, k := range keys {
+			child := xml.StartElement{Name: xml.Name{Local: k.String()}}
+			if err := marshalXml(enc, v.MapIndex(k), child, true); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return enc.EncodeElement(v.Interface(), start)
+		}
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		item := xml.StartElement{Name: xml.Name{Local: xmlItem}}
+		for i := range v.Len() {
+			if err := marshalXml(enc, v.Index(i), item, false); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	}
+	return enc.EncodeElement(v.Interface(), start)
+}
+
+func encodeXmlEmpty(enc *xml.Encoder, start xml.StartElement) error {
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	return enc.EncodeToken(start.End())
+}
@@ -0,0 +1,220 @@
+package rsvp_test
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/Teajey/rsvp"
+	"github.com/Teajey/rsvp/internal/assert"
+)
+
+type xmlUser struct {
+	Name string `xml:"name"`
+}
+
+func renderXml(t *testing.T, res rsvp.Body, cfg rsvp.Config) string {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/data.xml", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(res, cfg)(rec, req)
+	assert.FatalErr(t, "handler", err)
+	assert.Eq(t, "status", 200, rec.Code)
+	assert.Eq(t, "content type", "application/xml", rec.Header().Get("Content-Type"))
+
+	return rec.Body.String()
+}
+
+func TestXmlSliceIsWrapped(t *testing.T) {
+	actual := renderXml(t, rsvp.Data([]xmlUser{{Name: "Alice"}, {Name: "Bob"}}), rsvp.Config{})
+
+	assert.Eq(t, "body", "<data><xmlUser><name>Alice</name></xmlUser><xmlUser><name>Bob</name></xmlUser></data>", actual)
+}
+
+func TestXmlSliceOfPrimitives(t *testing.T) {
+	actual := renderXml(t, rsvp.Data([]any{"a", 1, true, nil}), rsvp.Config{})
+
+	assert.Eq(t, "body", "<data><item>a</item><item>1</item><item>true</item><item></item></data>", actual)
+}
+
+func TestXmlPrimitive(t *testing.T) {
+	actual := renderXml(t, rsvp.Data("a < b"), rsvp.Config{})
+
+	assert.Eq(t, "body", "<data>a &lt; b</data>", actual)
+}
+
+func TestXmlMapHasSortedChildren(t *testing.T) {
+	data := map[string]any{
+		"total": 2,
+		"users": []xmlUser{{Name: "Alice"}, {Name: "Bob"}},
+		"owner": xmlUser{Name: "Alice"},
+		"meta":  map[string]string{"b": "2", "a": "1"},
+	}
+
+	actual := renderXml(t, rsvp.Data(data), rsvp.Config{})
+
+	assert.Eq(t, "body", "<data><meta><a>1</a><b>2</b></meta><owner><name>Alice</name></owner><total>2</total><users><xmlUser><name>Alice</name></xmlUser><xmlUser><name>Bob</name></xmlUser></users></data>", actual)
+}
+
+func TestXmlRoot(t *testing.T) {
+	cfg := rsvp.Config{XmlRoot: "response", XmlIndent: "  "}
+
+	actual := renderXml(t, rsvp.Data([]string{"a"}), cfg)
+	assert.Eq(t, "config root", "<response>\n  <item>a</item>\n</response>", actual)
+
+	actual = renderXml(t, rsvp.Data([]xmlUser{}).XmlRoot("users"), cfg)
+	assert.Eq(t, "body root", "<users></users>", actual)
+}
+
+func TestXmlStructKeepsItsName(t *testing.T) {
+	actual := renderXml(t, rsvp.Data(&xmlUser{Name: "Alice"}).XmlRoot("users"), rsvp.Config{})
+
+	assert.Eq(t, "body", "<xmlUser><name>Alice</name></xmlUser>", actual)
+}
+
+func TestXmlAnonymousStructIsNamedByRoot(t *testing.T) {
+	actual := renderXml(t, rsvp.Data(struct{ A int }{1}), rsvp.Config{})
+	assert.Eq(t, "anonymous struct", "<data><A>1</A></data>", actual)
+
+	actual = renderXml(t, rsvp.Data(struct{}{}).XmlRoot("empty"), rsvp.Config{})
+	assert.Eq(t, "empty struct", "<empty></empty>", actual)
+}
+
+func TestXmlAnonymousStructItemsAreNamedItem(t *testing.T) {
+	actual := renderXml(t, rsvp.Data([]struct{ A int }{{1}, {2}}), rsvp.Config{})
+
+	assert.Eq(t, "body", "<data><item><A>1</A></item><item><A>2</A></item></data>", actual)
+}
+
+type xmlGeneric[T any] struct {
+	Value T
+}
+
+func TestXmlGenericStructIsNamedWithoutTypeArguments(t *testing.T) {
+	actual := renderXml(t, rsvp.Data(xmlGeneric[int]{1}), rsvp.Config{})
+	assert.Eq(t, "root", "<xmlGeneric><Value>1</Value></xmlGeneric>", actual)
+
+	actual = renderXml(t, rsvp.Data([]xmlGeneric[string]{{"a"}}), rsvp.Config{})
+	assert.Eq(t, "items", "<data><xmlGeneric><Value>a</Value></xmlGeneric></data>", actual)
+}
+
+type xmlWithMap struct {
+	Tags map[string]string
+}
+
+func TestXmlIsWithdrawnWhenUnrepresentable(t *testing.T) {
+	cases := map[string]any{
+		"channel of funcs": make(chan func()),
+		"func":             func() {},
+		"non-string keys":  map[int]string{1: "a"},
+		"invalid key name": map[string]int{"1st": 1},
+		"map in struct":    xmlWithMap{Tags: map[string]string{"a": "b"}},
+		"nested channel":   []any{"a", make(chan int)},
+	}
+
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			res := rsvp.Data(data)
+			mediaTypes := slices.Collect(res.MediaTypes(rsvp.Config{}))
+			assert.True(t, "XML is not offered", !slices.Contains(mediaTypes, rsvp.SupportedMediaTypeXml))
+		})
+	}
+}
+
+type xmlTree struct {
+	Name     string     `xml:"name"`
+	Children []*xmlTree `xml:"child"`
+	Done     chan bool  `xml:"-"`
+}
+
+type xmlWithOptional struct {
+	Name   string      `xml:"name"`
+	Detail *xmlWithMap `xml:"detail"`
+}
+
+func TestXmlIsOfferedWhenRepresentable(t *testing.T) {
+	cases := map[string]any{
+		"recursive type":        xmlTree{Name: "root", Children: []*xmlTree{{Name: "leaf"}}},
+		"nil pointer":           xmlWithOptional{Name: "a"},
+		"empty slice of funcs":  []func(){},
+		"interface of a string": []any{"a"},
+		"valid key names":       map[string]any{"a": 1},
+	}
+
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			res := rsvp.Data(data)
+			mediaTypes := slices.Collect(res.MediaTypes(rsvp.Config{}))
+			assert.True(t, "XML is offered", slices.Contains(mediaTypes, rsvp.SupportedMediaTypeXml))
+		})
+	}
+}
+
+func TestXmlUnrepresentableByExtensionIsNotFound(t *testing.T) {
+	req := httptest.NewRequest("GET", "/data.xml", nil)
+	rec := httptest.NewRecorder()
+
+	err := makeHandler(rsvp.Data(map[int]string{1: "a"}), rsvp.Config{})(rec, req)
+	assert.FatalErr(t, "handler", err)
+
+	assert.Eq(t, "status", 404, rec.Code)
+	assert.Eq(t, "content type", "application/json", rec.Header().Get("Content-Type"))
+}
+
+func TestXmlDeclarationAndStylesheet(t *testing.T) {
+	cfg := rsvp.Config{XmlOptions: rsvp.XmlOptions{Declaration: true, Stylesheet: "/feed.xsl?a=1&b=2"}}
+
+	actual := renderXml(t, rsvp.Data([]string{"a"}), cfg)
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<?xml-stylesheet type="text/xsl" href="/feed.xsl?a=1&amp;b=2"?>
+<data><item>a</item></data>`
+	assert.Eq(t, "body", expected, actual)
+}
+
+func TestXmlCssStylesheet(t *testing.T) {
+	cfg := rsvp.Config{XmlIndent: "  "}
+	res := rsvp.Data(xmlUser{Name: "Alice"}).XmlOptions(rsvp.XmlOptions{Stylesheet: "/user.css"})
+
+	actual := renderXml(t, res, cfg)
+
+	expected := `<?xml-stylesheet type="text/css" href="/user.css"?>
+<xmlUser>
+  <name>Alice</name>
+</xmlUser>`
+	assert.Eq(t, "body", expected, actual)
+}
+
+type xmlFeed struct {
+	XMLName xml.Name `xml:"feed"`
+	Title   string   `xml:"title"`
+	Link    string   `xml:"atom:link"`
+}
+
+func TestXmlNamespaces(t *testing.T) {
+	opts := rsvp.XmlOptions{
+		Namespace:  "urn:example:feed",
+		Namespaces: map[string]string{"dc": "http://purl.org/dc/elements/1.1/", "atom": "http://www.w3.org/2005/Atom"},
+	}
+
+	actual := renderXml(t, rsvp.Data(xmlFeed{Title: "News", Link: "/"}).XmlOptions(opts), rsvp.Config{})
+	expected := `<feed xmlns="urn:example:feed" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/"><title>News</title><atom:link>/</atom:link></feed>`
+	assert.Eq(t, "struct root", expected, actual)
+
+	actual = renderXml(t, rsvp.Data(map[string]int{"a": 1}).XmlOptions(rsvp.XmlOptions{Namespace: "urn:example:map"}), rsvp.Config{})
+	assert.Eq(t, "wrapped root", `<data xmlns="urn:example:map"><a>1</a></data>`, actual)
+}
+
+type xmlNamespaced struct {
+	XMLName xml.Name `xml:"urn:example:own thing"`
+}
+
+func TestXmlNamespaceDoesNotReplaceTheRootsOwn(t *testing.T) {
+	res := rsvp.Data(xmlNamespaced{}).XmlOptions(rsvp.XmlOptions{Namespace: "urn:example:other"})
+
+	actual := renderXml(t, res, rsvp.Config{})
+
+	assert.Eq(t, "body", `<thing xmlns="urn:example:own"></thing>`, actual)
+}